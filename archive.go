@@ -0,0 +1,331 @@
+package compressjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultArchiveBlockSize is the number of records per compressed block used by ArchiveWriter
+// when WithArchiveBlockSize is not set. Larger blocks compress better, smaller blocks make
+// random access cheaper, because Archive.Get decompresses one whole block.
+const DefaultArchiveBlockSize = 32
+
+// ErrInvalidArchive reports data that is not a well-formed archive written by ArchiveWriter.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Archive layout. Records are grouped into blocks; every block is an independent Z - standard frame
+// holding newline-delimited JSON. The index follows as a skippable frame, which standard decoders
+// ignore, so the whole archive still decompresses to plain NDJSON with any zstd tool:
+//
+//	block frame 0 | block frame 1 | ... | skippable frame: index entries, footer
+//
+// The index holds the number of blocks followed by the compressed size and record count of every
+// block as unsigned varints. The footer at the very end holds the size of the skippable frame as
+// a little-endian uint32 followed by archiveMagic, so readers can locate the index from the end.
+var (
+	archiveMagic        = []byte{'C', 'J', 'A', 1}
+	skippableFrameMagic = []byte{0x5e, 0x2a, 0x4d, 0x18}
+)
+
+// archiveFooterLen is the size of the footer: the skippable frame size and archiveMagic.
+const archiveFooterLen = 8
+
+// archiveBlock locates a block of records inside an archive.
+type archiveBlock struct {
+	offset int64
+	size   int64
+	first  int
+	count  int
+}
+
+// ArchiveWriter writes values of type T into a seekable archive that Archive can read
+// by position without decompressing the preceding records.
+//
+// An ArchiveWriter is not safe for concurrent use. Close must be called to write the index.
+type ArchiveWriter[T any] struct {
+	w      io.Writer
+	t      *transcoder[T]
+	size   int
+	block  []byte
+	count  int
+	blocks []archiveBlock
+	offset int64
+	closed bool
+}
+
+// NewArchiveWriter creates an ArchiveWriter that writes to w. The JSON options of opts apply
+// to every record, and WithArchiveBlockSize controls the number of records per block.
+func NewArchiveWriter[T any](w io.Writer, opts ...Option) *ArchiveWriter[T] {
+	aw := &ArchiveWriter[T]{w: w, t: newTranscoder[T](opts...)}
+
+	aw.size = aw.t.opts.archiveBlockSize
+	if aw.size <= 0 {
+		aw.size = DefaultArchiveBlockSize
+	}
+
+	return aw
+}
+
+// Write appends v to the archive. A block is compressed and written to the underlying writer
+// whenever it is full.
+func (w *ArchiveWriter[T]) Write(v T) error {
+	if w.closed {
+		return errors.New("archive writer is closed")
+	}
+
+	jsonBytes, err := w.t.jsonTranscoder.Marshal(v)
+	if err != nil {
+		w.t.logEncodeFailure("json", v, err)
+		return errors.Join(ErrMarshalJSON, err)
+	}
+
+	w.block = append(append(w.block, jsonBytes...), '\n')
+	w.count++
+
+	if w.count == w.size {
+		return w.flush()
+	}
+
+	return nil
+}
+
+// flush compresses the pending records into a frame and writes it.
+func (w *ArchiveWriter[T]) flush() error {
+	if w.count == 0 {
+		return nil
+	}
+
+	frame, err := w.t.standardTranscoder.Compress(w.block)
+	if err != nil {
+		return errors.Join(ErrCompress, err)
+	}
+
+	if _, err = w.w.Write(frame); err != nil {
+		return err
+	}
+
+	first := 0
+	if n := len(w.blocks); n > 0 {
+		first = w.blocks[n-1].first + w.blocks[n-1].count
+	}
+
+	w.blocks = append(w.blocks, archiveBlock{offset: w.offset, size: int64(len(frame)), first: first, count: w.count})
+	w.offset += int64(len(frame))
+	w.block, w.count = w.block[:0], 0
+
+	return nil
+}
+
+// Close writes the last partial block and the index. It does not close the underlying writer.
+func (w *ArchiveWriter[T]) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	index := binary.AppendUvarint(nil, uint64(len(w.blocks)))
+	for _, b := range w.blocks {
+		index = binary.AppendUvarint(index, uint64(b.size))
+		index = binary.AppendUvarint(index, uint64(b.count))
+	}
+
+	// Skippable frame: magic, payload size, payload. The payload ends with the footer.
+	payloadLen := len(index) + archiveFooterLen
+	frame := make([]byte, 0, 8+payloadLen)
+	frame = append(frame, skippableFrameMagic...)
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(payloadLen))
+	frame = append(frame, index...)
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(8+payloadLen))
+	frame = append(frame, archiveMagic...)
+
+	_, err := w.w.Write(frame)
+
+	return err
+}
+
+// Archive provides random access to the records of an archive written by ArchiveWriter.
+// Only the index is read when the archive is opened; Get reads and decompresses the single block
+// holding the requested record. The most recently used block is kept decompressed, so reading
+// records in order decompresses every block once.
+//
+// An Archive is safe for concurrent use if the underlying io.ReaderAt is.
+type Archive[T any] struct {
+	r      io.ReaderAt
+	t      *transcoder[T]
+	blocks []archiveBlock
+	len    int
+
+	mu        sync.Mutex
+	cached    int
+	cachedRec [][]byte
+}
+
+// OpenArchive reads the index of the archive of the given size from r.
+// The JSON options of opts apply to every record returned by Get.
+func OpenArchive[T any](r io.ReaderAt, size int64, opts ...Option) (*Archive[T], error) {
+	if size < 8+archiveFooterLen {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidArchive)
+	}
+
+	footer := make([]byte, archiveFooterLen)
+	if err := readAt(r, footer, size-archiveFooterLen); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(footer[4:], archiveMagic) {
+		return nil, fmt.Errorf("%w: missing magic number", ErrInvalidArchive)
+	}
+
+	frameLen := int64(binary.LittleEndian.Uint32(footer))
+	if frameLen < 8+archiveFooterLen || frameLen > size {
+		return nil, fmt.Errorf("%w: index size %d", ErrInvalidArchive, frameLen)
+	}
+
+	frame := make([]byte, frameLen)
+	if err := readAt(r, frame, size-frameLen); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(frame[:4], skippableFrameMagic) || int64(binary.LittleEndian.Uint32(frame[4:8])) != frameLen-8 {
+		return nil, fmt.Errorf("%w: index is not a skippable frame", ErrInvalidArchive)
+	}
+
+	blocks, err := parseArchiveIndex(frame[8:frameLen-archiveFooterLen], size-frameLen)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive[T]{r: r, t: newTranscoder[T](opts...), blocks: blocks, cached: -1}
+	if n := len(blocks); n > 0 {
+		a.len = blocks[n-1].first + blocks[n-1].count
+	}
+
+	return a, nil
+}
+
+// readAt fills buf from r at offset off. Readers may report io.EOF together with a complete read
+// that ends at the end of their data, as the io.ReaderAt contract permits; that is not an error.
+func readAt(r io.ReaderAt, buf []byte, off int64) error {
+	n, err := r.ReadAt(buf, off)
+	if n == len(buf) && (err == nil || errors.Is(err, io.EOF)) {
+		return nil
+	}
+
+	if err == nil || errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, io.ErrUnexpectedEOF)
+	}
+
+	return err
+}
+
+// parseArchiveIndex decodes the index entries and checks that the blocks exactly fill dataLen bytes.
+func parseArchiveIndex(src []byte, dataLen int64) ([]archiveBlock, error) {
+	n, read := binary.Uvarint(src)
+	if read <= 0 || n > uint64(len(src)) {
+		return nil, fmt.Errorf("%w: block count", ErrInvalidArchive)
+	}
+
+	src = src[read:]
+	blocks := make([]archiveBlock, 0, n)
+
+	var offset int64
+	var first int
+
+	for i := uint64(0); i < n; i++ {
+		// The entries are untrusted: a block must fit into the remaining data, and the record
+		// positions must not overflow, so that neither can cause huge allocations in Get.
+		size, read := binary.Uvarint(src)
+		if read <= 0 || size > uint64(dataLen-offset) {
+			return nil, fmt.Errorf("%w: block %d", ErrInvalidArchive, i)
+		}
+
+		src = src[read:]
+
+		count, read := binary.Uvarint(src)
+		if read <= 0 || count == 0 || count > uint64(math.MaxInt-first) {
+			return nil, fmt.Errorf("%w: block %d", ErrInvalidArchive, i)
+		}
+
+		src = src[read:]
+
+		blocks = append(blocks, archiveBlock{offset: offset, size: int64(size), first: first, count: int(count)})
+		offset += int64(size)
+		first += int(count)
+	}
+
+	if offset != dataLen {
+		return nil, fmt.Errorf("%w: blocks cover %d of %d bytes", ErrInvalidArchive, offset, dataLen)
+	}
+
+	return blocks, nil
+}
+
+// Len returns the number of records in the archive.
+func (a *Archive[T]) Len() int {
+	return a.len
+}
+
+// Get returns the record at position i, counting from zero.
+func (a *Archive[T]) Get(i int) (T, error) {
+	var entry T
+
+	if i < 0 || i >= a.len {
+		return entry, fmt.Errorf("record %d out of range [0, %d)", i, a.len)
+	}
+
+	idx := sort.Search(len(a.blocks), func(n int) bool { return a.blocks[n].first+a.blocks[n].count > i })
+
+	records, err := a.block(idx)
+	if err != nil {
+		return entry, err
+	}
+
+	return a.t.decodeJSON(records[i-a.blocks[idx].first])
+}
+
+// block returns the records of block idx, reading and decompressing it unless it is cached.
+func (a *Archive[T]) block(idx int) ([][]byte, error) {
+	a.mu.Lock()
+	if a.cached == idx {
+		records := a.cachedRec
+		a.mu.Unlock()
+
+		return records, nil
+	}
+	a.mu.Unlock()
+
+	b := a.blocks[idx]
+
+	frame := make([]byte, b.size)
+	if err := readAt(a.r, frame, b.offset); err != nil {
+		return nil, err
+	}
+
+	data, err := a.t.standardTranscoder.Decompress(frame)
+	if err != nil {
+		a.t.logDecodeFailure("zstd", string(frame), err)
+		return nil, decompressError(err)
+	}
+
+	records := bytes.SplitAfter(bytes.TrimSuffix(data, []byte{'\n'}), []byte{'\n'})
+	if len(records) != b.count {
+		return nil, fmt.Errorf("%w: block %d holds %d of %d records", ErrInvalidArchive, idx, len(records), b.count)
+	}
+
+	a.mu.Lock()
+	a.cached, a.cachedRec = idx, records
+	a.mu.Unlock()
+
+	return records, nil
+}
@@ -0,0 +1,213 @@
+package compressjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestArchive is the table-driven test for ArchiveWriter[T] and Archive[T]. For several block sizes
+// it writes an archive, reads every record by position in random and sequential order, and checks
+// that the archive still decompresses to plain NDJSON with a standard decoder.
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		opts      []Option
+		records   int
+		wantBlock int
+	}{
+		{name: "Default block size", records: 100, wantBlock: 4},
+		{name: "One record per block", opts: []Option{WithArchiveBlockSize(1)}, records: 10, wantBlock: 10},
+		{name: "Exactly full blocks", opts: []Option{WithArchiveBlockSize(5)}, records: 20, wantBlock: 4},
+		{name: "Empty archive", records: 0, wantBlock: 0},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := NewArchiveWriter[user](&buf, tt.opts...)
+			for i := 0; i < tt.records; i++ {
+				assert.NoError(t, w.Write(user{ID: i, Name: fmt.Sprintf("user-%d", i)}))
+			}
+
+			assert.NoError(t, w.Close())
+
+			a, err := OpenArchive[user](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.records, a.Len())
+			assert.Len(t, a.blocks, tt.wantBlock)
+
+			for _, i := range []int{tt.records - 1, 0, tt.records / 2} {
+				if tt.records == 0 {
+					break
+				}
+
+				got, err := a.Get(i)
+				assert.NoError(t, err)
+				assert.Equal(t, user{ID: i, Name: fmt.Sprintf("user-%d", i)}, got)
+			}
+
+			for i := 0; i < tt.records; i++ {
+				got, err := a.Get(i)
+				assert.NoError(t, err)
+				assert.Equal(t, i, got.ID)
+			}
+
+			_, err = a.Get(tt.records)
+			assert.Error(t, err)
+
+			plain, err := zstdStage.Decompress(buf.Bytes())
+			assert.NoError(t, err, "Standard decoders must skip the index")
+			assert.Equal(t, tt.records, strings.Count(string(plain), "\n"))
+		})
+	}
+}
+
+// TestOpenArchiveErrors verifies that truncated and foreign data is rejected with ErrInvalidArchive.
+func TestOpenArchiveErrors(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	w := NewArchiveWriter[user](&buf, WithArchiveBlockSize(2))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, w.Write(user{ID: i}))
+	}
+
+	assert.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	frame, err := zstdStage.Compress([]byte(`{"id":1}`))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "Too short", input: []byte("CJA")},
+		{name: "Plain frame", input: frame},
+		{name: "Missing first block", input: data[10:]},
+		{name: "Extra leading data", input: append([]byte("garbage"), data...)},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenArchive[user](bytes.NewReader(tt.input), int64(len(tt.input)))
+			assert.ErrorIs(t, err, ErrInvalidArchive)
+		})
+	}
+}
+
+// craftArchive appends an index with the given block sizes and record counts to data,
+// without checking that the entries describe data.
+func craftArchive(data []byte, entries ...[2]uint64) []byte {
+	index := binary.AppendUvarint(nil, uint64(len(entries)))
+	for _, e := range entries {
+		index = binary.AppendUvarint(index, e[0])
+		index = binary.AppendUvarint(index, e[1])
+	}
+
+	payloadLen := len(index) + archiveFooterLen
+	out := append([]byte(nil), data...)
+	out = append(out, skippableFrameMagic...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(payloadLen))
+	out = append(out, index...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(8+payloadLen))
+
+	return append(out, archiveMagic...)
+}
+
+// TestOpenArchiveCraftedIndex verifies that index entries that do not fit the archive are rejected
+// when it is opened, instead of causing huge allocations or overflows when records are read.
+func TestOpenArchiveCraftedIndex(t *testing.T) {
+	t.Parallel()
+
+	frame, err := zstdStage.Compress([]byte("{\"id\":1}\n"))
+	assert.NoError(t, err)
+
+	size := uint64(len(frame))
+
+	cases := []struct {
+		name    string
+		input   []byte
+		wantErr bool
+	}{
+		{name: "Valid index", input: craftArchive(frame, [2]uint64{size, 1})},
+		{name: "Huge block size", input: craftArchive(frame, [2]uint64{math.MaxInt64, 1}), wantErr: true},
+		{name: "Wrapping block sizes", input: craftArchive(frame, [2]uint64{math.MaxUint64, 1}, [2]uint64{size + 1, 1}), wantErr: true},
+		{name: "Block beyond the data", input: craftArchive(frame, [2]uint64{size, 1}, [2]uint64{1 << 40, 1}), wantErr: true},
+		{name: "Huge record count", input: craftArchive(frame, [2]uint64{size, math.MaxUint64}), wantErr: true},
+		{name: "Overflowing record counts", input: craftArchive(append(frame, frame...), [2]uint64{size, math.MaxInt}, [2]uint64{size, 2}), wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := OpenArchive[user](bytes.NewReader(tt.input), int64(len(tt.input)))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidArchive)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			got, err := a.Get(0)
+			assert.NoError(t, err)
+			assert.Equal(t, user{ID: 1}, got)
+		})
+	}
+}
+
+// eofReaderAt is an io.ReaderAt that reports io.EOF together with every read reaching the end
+// of its data, as os.File and other implementations may.
+type eofReaderAt struct {
+	data []byte
+}
+
+func (r eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.data[off:])
+	if off+int64(n) == int64(len(r.data)) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// TestArchiveReaderAtEOF verifies that archives are read from readers that report io.EOF with
+// complete reads at the end of their data, and that truncated data is still rejected.
+func TestArchiveReaderAtEOF(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	w := NewArchiveWriter[user](&buf, WithArchiveBlockSize(1))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, w.Write(user{ID: i}))
+	}
+
+	assert.NoError(t, w.Close())
+
+	a, err := OpenArchive[user](eofReaderAt{data: buf.Bytes()}, int64(buf.Len()))
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		got, err := a.Get(i)
+		assert.NoError(t, err)
+		assert.Equal(t, i, got.ID)
+	}
+
+	_, err = OpenArchive[user](eofReaderAt{data: buf.Bytes()[:buf.Len()-1]}, int64(buf.Len()))
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+}
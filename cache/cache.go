@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spacemagneto/compressjson"
+)
+
+// Backend is the minimal storage contract required by Cache. It deals with opaque byte
+// values only, which makes it straightforward to implement on top of Redis, Memcached
+// or any other key-value store. Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the value stored under key. The boolean result is false when the key
+	// does not exist or has expired; that is not an error.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores val under key. A ttl less than or equal to zero means the entry never expires.
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// LoadFunc produces the value for a key that is missing from the cache.
+type LoadFunc[T any] func(ctx context.Context) (T, error)
+
+// Cache stores values of type T in a Backend using the compact text representation
+// produced by a compressjson.Transcoder. It is safe for concurrent use.
+type Cache[T any] struct {
+	backend    Backend
+	transcoder compressjson.Transcoder[T]
+	group      group[T]
+}
+
+// New creates a cache for values of type T on top of backend. Values are converted with
+// transcoder; if it is nil, a default compressjson.NewTranscoder[T] is used.
+func New[T any](backend Backend, transcoder compressjson.Transcoder[T]) *Cache[T] {
+	if transcoder == nil {
+		transcoder = compressjson.NewTranscoder[T]()
+	}
+
+	return &Cache[T]{backend: backend, transcoder: transcoder}
+}
+
+// Get returns the value stored under key. The boolean result reports whether the key was found.
+// A stored value that cannot be decoded is reported as an error together with found set to true.
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var entry T
+
+	raw, found, err := c.backend.Get(ctx, key)
+	if err != nil || !found {
+		return entry, false, err
+	}
+
+	entry, err = c.transcoder.Decode(string(raw))
+	if err != nil {
+		return entry, true, errors.Join(errors.New("failed to decode cached value"), err)
+	}
+
+	return entry, true, nil
+}
+
+// Set encodes v and stores it under key. A ttl less than or equal to zero means the entry never expires.
+func (c *Cache[T]) Set(ctx context.Context, key string, v T, ttl time.Duration) error {
+	encoded, err := c.transcoder.Encode(v)
+	if err != nil {
+		return errors.Join(errors.New("failed to encode cached value"), err)
+	}
+
+	return c.backend.Set(ctx, key, []byte(encoded), ttl)
+}
+
+// Delete removes key from the cache.
+func (c *Cache[T]) Delete(ctx context.Context, key string) error {
+	return c.backend.Delete(ctx, key)
+}
+
+// GetOrLoad returns the value stored under key, calling load and storing its result with
+// the given ttl on a miss. Concurrent misses for the same key are deduplicated: load runs
+// once and every waiting caller receives its result. The context of the caller that
+// triggered the load is the one passed to load.
+//
+// A stored value that cannot be decoded is treated as a miss and overwritten, so a single
+// corrupted entry heals itself instead of failing every read. Errors from load are returned
+// as is and nothing is stored. If load panics, the panic propagates in the caller that ran it,
+// and the callers waiting for the same key receive an error wrapping ErrLoadPanicked.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load LoadFunc[T]) (T, error) {
+	entry, found, err := c.Get(ctx, key)
+	if found && err == nil {
+		return entry, nil
+	}
+
+	if !found && err != nil {
+		return entry, err
+	}
+
+	return c.group.do(key, func() (T, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return value, err
+		}
+
+		if err = c.Set(ctx, key, value, ttl); err != nil {
+			return value, err
+		}
+
+		return value, nil
+	})
+}
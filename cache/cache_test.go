@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	mocks "github.com/spacemagneto/compressjson/mocks"
+)
+
+type profile struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// TestCache is the table-driven test for the Get, Set and Delete methods of Cache[T].
+// It verifies round-trip fidelity through the memory backend, reporting of missing keys,
+// and that values which cannot be decoded surface as errors instead of zero values.
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	c := New[profile](backend, nil)
+
+	cases := []struct {
+		name      string
+		key       string
+		stored    []byte
+		value     *profile
+		wantFound bool
+		wantErr   bool
+	}{
+		{name: "Stored value", key: "p:1", value: &profile{ID: 1, Name: "Ada", Roles: []string{"admin"}}, wantFound: true},
+		{name: "Missing key", key: "p:2"},
+		{name: "Corrupted value", key: "p:3", stored: []byte("corrupted"), wantFound: true, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != nil {
+				assert.NoError(t, c.Set(ctx, tt.key, *tt.value, time.Minute))
+			}
+
+			if tt.stored != nil {
+				assert.NoError(t, backend.Set(ctx, tt.key, tt.stored, 0))
+			}
+
+			got, found, err := c.Get(ctx, tt.key)
+			assert.Equal(t, tt.wantFound, found)
+
+			if tt.wantErr {
+				assert.Error(t, err, "Get must fail for a value that cannot be decoded")
+				return
+			}
+
+			assert.NoError(t, err)
+			if tt.value != nil {
+				assert.Equal(t, *tt.value, got)
+			}
+
+			assert.NoError(t, c.Delete(ctx, tt.key))
+
+			_, found, err = c.Get(ctx, tt.key)
+			assert.NoError(t, err)
+			assert.False(t, found, "Deleted key must not be found")
+		})
+	}
+}
+
+// TestCacheSetEncodeError verifies that Set reports transcoder failures and stores nothing.
+func TestCacheSetEncodeError(t *testing.T) {
+	t.Parallel()
+
+	tr := mocks.NewMockTranscoder[profile](t)
+	tr.EXPECT().Encode(mock.Anything).Return("", errors.New("boom"))
+
+	backend := NewMemoryBackend()
+	c := New[profile](backend, tr)
+
+	assert.Error(t, c.Set(context.Background(), "p:1", profile{ID: 1}, 0))
+	assert.Zero(t, backend.Len(), "Nothing must be stored when encoding fails")
+}
+
+// TestCacheGetOrLoad covers the load path of GetOrLoad: values are loaded once and stored,
+// load errors are returned without storing anything, and corrupted entries are reloaded.
+func TestCacheGetOrLoad(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	c := New[profile](backend, nil)
+
+	var loads atomic.Int32
+	load := func(context.Context) (profile, error) {
+		loads.Add(1)
+		return profile{ID: 7, Name: "Grace"}, nil
+	}
+
+	t.Run("Load on miss and serve from cache afterwards", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			got, err := c.GetOrLoad(ctx, "p:7", time.Minute, load)
+			assert.NoError(t, err)
+			assert.Equal(t, profile{ID: 7, Name: "Grace"}, got)
+		}
+
+		assert.Equal(t, int32(1), loads.Load(), "Load must run exactly once")
+	})
+
+	t.Run("Load error is returned and not stored", func(t *testing.T) {
+		_, err := c.GetOrLoad(ctx, "p:8", time.Minute, func(context.Context) (profile, error) {
+			return profile{}, errors.New("database down")
+		})
+
+		assert.EqualError(t, err, "database down")
+
+		_, found, _ := c.Get(ctx, "p:8")
+		assert.False(t, found, "Failed loads must not be cached")
+	})
+
+	t.Run("Corrupted entry is reloaded", func(t *testing.T) {
+		assert.NoError(t, backend.Set(ctx, "p:9", []byte("corrupted"), 0))
+
+		got, err := c.GetOrLoad(ctx, "p:9", 0, func(context.Context) (profile, error) {
+			return profile{ID: 9}, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, profile{ID: 9}, got)
+
+		got, found, err := c.Get(ctx, "p:9")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, profile{ID: 9}, got, "Reloaded value must replace the corrupted entry")
+	})
+}
+
+// TestCacheGetOrLoadDeduplication verifies that concurrent misses for the same key
+// share a single in-flight load.
+func TestCacheGetOrLoadDeduplication(t *testing.T) {
+	t.Parallel()
+
+	c := New[profile](NewMemoryBackend(), nil)
+
+	const callers = 32
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	load := func(context.Context) (profile, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+
+		<-release
+
+		return profile{ID: 1, Name: "shared"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]profile, callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			got, err := c.GetOrLoad(context.Background(), "shared", 0, load)
+			assert.NoError(t, err)
+			results[i] = got
+		}(i)
+	}
+
+	<-started
+	// Give the remaining callers time to join the in-flight load before it completes.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.LessOrEqual(t, loads.Load(), int32(2), "Concurrent misses must be deduplicated")
+
+	for _, got := range results {
+		assert.Equal(t, profile{ID: 1, Name: "shared"}, got)
+	}
+}
+
+// TestCacheGetOrLoadPanic verifies that a panicking load is re-raised in the caller running it,
+// while callers waiting for the same key receive ErrLoadPanicked instead of a zero value.
+func TestCacheGetOrLoadPanic(t *testing.T) {
+	t.Parallel()
+
+	c := New[profile](NewMemoryBackend(), nil)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	load := func(context.Context) (profile, error) {
+		close(started)
+		<-release
+
+		panic("backend exploded")
+	}
+
+	leader := make(chan any, 1)
+
+	go func() {
+		defer func() { leader <- recover() }()
+
+		_, _ = c.GetOrLoad(context.Background(), "panic", 0, load)
+	}()
+
+	<-started
+
+	waiter := make(chan error, 1)
+
+	go func() {
+		_, err := c.GetOrLoad(context.Background(), "panic", 0, func(context.Context) (profile, error) {
+			return profile{ID: 1}, nil
+		})
+		waiter <- err
+	}()
+
+	// Give the waiter time to join the in-flight load before it panics.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, "backend exploded", <-leader, "The panic must propagate in the loading caller")
+
+	err := <-waiter
+	assert.ErrorIs(t, err, ErrLoadPanicked)
+	assert.ErrorContains(t, err, "backend exploded")
+
+	got, err := c.GetOrLoad(context.Background(), "panic", 0, func(context.Context) (profile, error) {
+		return profile{ID: 2}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, profile{ID: 2}, got, "A panicking load must not block later loads")
+}
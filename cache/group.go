@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrLoadPanicked is returned by GetOrLoad to callers that waited for a load which panicked
+// or exited its goroutine. The panic itself propagates in the caller that ran the load.
+var ErrLoadPanicked = errors.New("cache: load panicked")
+
+// call is a load in progress or completed within a group.
+type call[T any] struct {
+	wg    sync.WaitGroup
+	value T
+	err   error
+}
+
+// group deduplicates concurrent loads of the same key, in the spirit of
+// golang.org/x/sync/singleflight but typed and without the extra dependency.
+// The zero value is ready to use.
+type group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// do executes fn for key, making sure that only one execution is in flight for a given key
+// at a time. Callers arriving while fn is running wait for it and receive the same result.
+// If fn panics, the panic is re-raised in the caller running fn and the waiting callers
+// receive an error wrapping ErrLoadPanicked instead of a zero value without an error.
+func (g *group[T]) do(key string, fn func() (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+
+		return c.value, c.err
+	}
+
+	c := new(call[T])
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	returned := false
+
+	defer func() {
+		// recover returns nil for runtime.Goexit, which also leaves fn without a result.
+		r := recover()
+		if !returned {
+			c.err = fmt.Errorf("%w: %v", ErrLoadPanicked, r)
+		}
+
+		c.wg.Done()
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	c.value, c.err = fn()
+	returned = true
+
+	return c.value, c.err
+}
@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/spacemagneto/compressjson"
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// memEntry is a compressed value held by MemCache.
+type memEntry struct {
+	data    []byte
+	logical int64
+	gen     uint64
+}
+
+// MemCacheStats is a point-in-time snapshot of MemCache usage counters.
+type MemCacheStats struct {
+	// Entries is the number of compressed entries currently stored.
+	Entries int
+	// CompressedBytes is the total size of all stored entries after compression.
+	// It never exceeds the budget given to NewMemCache.
+	CompressedBytes int64
+	// LogicalBytes is the total size of the JSON form of all stored entries.
+	LogicalBytes int64
+	// HotEntries is the number of decoded values currently kept in the hot tier.
+	HotEntries int
+	// Hits counts Get calls that found the key, in either tier.
+	Hits uint64
+	// HotHits counts the subset of Hits served from the hot tier without decoding.
+	HotHits uint64
+	// Misses counts Get calls that did not find the key.
+	Misses uint64
+	// Evictions counts entries removed to stay within the compressed-size budget.
+	Evictions uint64
+}
+
+// MemCacheOption configures optional behavior of a MemCache.
+type MemCacheOption func(*memCacheConfig)
+
+// memCacheConfig holds the optional settings of a MemCache.
+type memCacheConfig struct {
+	hotEntries int
+}
+
+// WithHotTier keeps up to n recently read values in decoded form next to the compressed entries.
+// Reads served from the hot tier skip decompression and unmarshalling entirely, at the cost of
+// keeping those values uncompressed in memory. Values returned from the hot tier are shared
+// between callers and must be treated as read-only.
+func WithHotTier(n int) MemCacheOption {
+	return func(c *memCacheConfig) {
+		c.hotEntries = n
+	}
+}
+
+// MemCache is an in-process LRU cache that keeps values of type T compressed in memory,
+// trading CPU time on reads for a much smaller memory footprint. Entries are marshaled to JSON
+// and compressed with Z - standard; the Base64 stage is skipped because nothing leaves the process.
+// The cache is bounded by the total compressed size of its entries. It is safe for concurrent use.
+type MemCache[T any] struct {
+	mu             sync.Mutex
+	entries        *lib.LRU[string, memEntry]
+	hot            *lib.LRU[string, T]
+	jsonTranscoder *lib.JSONTranscoder[T]
+	zstdTranscoder *lib.ZSTDTranscoder
+	stats          MemCacheStats
+	gen            uint64
+}
+
+// NewMemCache creates a cache whose compressed entries never exceed maxCompressedBytes in total.
+func NewMemCache[T any](maxCompressedBytes int64, opts ...MemCacheOption) *MemCache[T] {
+	var cfg memCacheConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c := &MemCache[T]{
+		jsonTranscoder: lib.NewJSONTranscoder[T](),
+		zstdTranscoder: lib.NewZSTDTranscoder(),
+	}
+
+	c.entries = lib.NewLRU[string, memEntry](maxCompressedBytes, func(key string, entry memEntry) {
+		c.stats.Evictions++
+		c.stats.LogicalBytes -= entry.logical
+
+		if c.hot != nil {
+			c.hot.Remove(key)
+		}
+	})
+
+	if cfg.hotEntries > 0 {
+		c.hot = lib.NewLRU[string, T](int64(cfg.hotEntries), nil)
+	}
+
+	return c
+}
+
+// Set compresses v and stores it under key, evicting the least recently used entries as needed.
+// The cache keeps no reference to v. A value whose compressed size alone exceeds the budget
+// is not stored, and any previous value under key is removed.
+func (c *MemCache[T]) Set(key string, v T) error {
+	jsonBytes, err := c.jsonTranscoder.Marshal(v)
+	if err != nil {
+		return errors.Join(compressjson.ErrMarshalJSON, err)
+	}
+
+	compressed, err := c.zstdTranscoder.Compress(jsonBytes)
+	if err != nil {
+		return errors.Join(compressjson.ErrCompress, err)
+	}
+
+	// The frame is compressed into a buffer sized for the JSON input. Keeping that buffer would
+	// retain the uncompressed size of every entry, so only a tight copy is stored.
+	compressed = append(make([]byte, 0, len(compressed)), compressed...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(key)
+
+	c.gen++
+	entry := memEntry{data: compressed, logical: int64(len(jsonBytes)), gen: c.gen}
+	if c.entries.Add(key, entry, int64(len(compressed))) {
+		c.stats.LogicalBytes += entry.logical
+	}
+
+	return nil
+}
+
+// Get returns the value stored under key and reports whether it was found.
+// Values are served from the hot tier when enabled, and decoded from their compressed form otherwise.
+func (c *MemCache[T]) Get(key string) (T, bool, error) {
+	var entry T
+
+	c.mu.Lock()
+
+	if c.hot != nil {
+		if value, ok := c.hot.Get(key); ok {
+			c.entries.Get(key)
+			c.stats.Hits++
+			c.stats.HotHits++
+			c.mu.Unlock()
+
+			return value, true, nil
+		}
+	}
+
+	stored, ok := c.entries.Get(key)
+	if !ok {
+		c.stats.Misses++
+		c.mu.Unlock()
+
+		return entry, false, nil
+	}
+
+	c.stats.Hits++
+	c.mu.Unlock()
+
+	// Decoding happens outside the lock so that concurrent readers do not serialize on it.
+	jsonBytes, err := c.zstdTranscoder.Decompress(stored.data)
+	if err != nil {
+		return entry, true, errors.Join(compressjson.ErrDecompress, err)
+	}
+
+	entry, err = c.jsonTranscoder.Unmarshal(jsonBytes)
+	if err != nil {
+		return entry, true, errors.Join(compressjson.ErrUnmarshalJSON, err)
+	}
+
+	if c.hot != nil {
+		c.mu.Lock()
+		// Only promote if the entry was not replaced or removed while decoding.
+		if current, ok := c.entries.Get(key); ok && current.gen == stored.gen {
+			c.hot.Add(key, entry, 1)
+		}
+		c.mu.Unlock()
+	}
+
+	return entry, true, nil
+}
+
+// Delete removes key from both tiers.
+func (c *MemCache[T]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(key)
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *MemCache[T]) Stats() MemCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.entries.Len()
+	stats.CompressedBytes = c.entries.Cost()
+
+	if c.hot != nil {
+		stats.HotEntries = c.hot.Len()
+	}
+
+	return stats
+}
+
+// removeLocked removes key from both tiers and updates the logical size counter.
+// The caller must hold c.mu.
+func (c *MemCache[T]) removeLocked(key string) {
+	if stored, ok := c.entries.Get(key); ok {
+		c.entries.Remove(key)
+		c.stats.LogicalBytes -= stored.logical
+	}
+
+	if c.hot != nil {
+		c.hot.Remove(key)
+	}
+}
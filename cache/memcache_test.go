@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/spacemagneto/compressjson"
+)
+
+// TestMemCache verifies round-trip fidelity of MemCache[T], the compressed and logical size
+// accounting, and that the compressed-size budget is enforced by evicting the least recently
+// used entries.
+func TestMemCache(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemCache[profile](4096)
+
+	want := profile{ID: 1, Name: strings.Repeat("compressible ", 100)}
+	assert.NoError(t, c.Set("p:1", want))
+
+	got, found, err := c.Get("p:1")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, want, got)
+
+	_, found, err = c.Get("missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	stats := c.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Greater(t, stats.LogicalBytes, stats.CompressedBytes, "Repetitive values must be stored compressed")
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+
+	for i := 2; i <= 200; i++ {
+		assert.NoError(t, c.Set(fmt.Sprintf("p:%d", i), profile{ID: i, Name: fmt.Sprintf("user-%d-%x", i, i*7919)}))
+	}
+
+	stats = c.Stats()
+	assert.LessOrEqual(t, stats.CompressedBytes, int64(4096), "Compressed budget must be respected")
+	assert.Positive(t, stats.Evictions)
+	assert.Equal(t, 200-int(stats.Evictions), stats.Entries)
+
+	_, found, _ = c.Get("p:1")
+	assert.False(t, found, "Oldest entry must be evicted first")
+
+	got, found, err = c.Get("p:200")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 200, got.ID)
+
+	c.Delete("p:200")
+	_, found, _ = c.Get("p:200")
+	assert.False(t, found)
+
+	// Logical size must drop back to zero once every entry is gone.
+	for i := 1; i <= 200; i++ {
+		c.Delete(fmt.Sprintf("p:%d", i))
+	}
+
+	stats = c.Stats()
+	assert.Zero(t, stats.Entries)
+	assert.Zero(t, stats.CompressedBytes)
+	assert.Zero(t, stats.LogicalBytes)
+}
+
+// TestMemCacheHotTier verifies that repeated reads are served from the hot tier,
+// and that overwriting or deleting a key invalidates its decoded value.
+func TestMemCacheHotTier(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemCache[profile](1<<20, WithHotTier(2))
+
+	assert.NoError(t, c.Set("a", profile{ID: 1}))
+
+	for i := 0; i < 3; i++ {
+		got, found, err := c.Get("a")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, profile{ID: 1}, got)
+	}
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(2), stats.HotHits, "Reads after the first must be served decoded")
+	assert.Equal(t, 1, stats.HotEntries)
+
+	assert.NoError(t, c.Set("a", profile{ID: 2}))
+
+	got, _, err := c.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, profile{ID: 2}, got, "Overwriting must invalidate the hot value")
+
+	c.Delete("a")
+	_, found, _ := c.Get("a")
+	assert.False(t, found)
+	assert.Zero(t, c.Stats().HotEntries)
+}
+
+// TestMemCacheCompactEntries verifies that stored entries hold only their compressed bytes,
+// so that the memory retained by the cache matches the compressed-size budget.
+func TestMemCacheCompactEntries(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemCache[profile](1 << 20)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, c.Set(fmt.Sprintf("p:%d", i), profile{ID: i, Name: strings.Repeat("x", 100<<10)}))
+	}
+
+	for i := 0; i < 10; i++ {
+		c.mu.Lock()
+		entry, ok := c.entries.Get(fmt.Sprintf("p:%d", i))
+		c.mu.Unlock()
+
+		assert.True(t, ok)
+		assert.Less(t, len(entry.data), 1<<10, "Highly compressible values must compress well")
+		assert.Equal(t, len(entry.data), cap(entry.data), "Entries must not retain the compression buffer")
+	}
+}
+
+// TestMemCacheSetError verifies that values which cannot be marshaled are rejected
+// without affecting the cache contents.
+func TestMemCacheSetError(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemCache[func()](1024)
+
+	assert.ErrorIs(t, c.Set("f", func() {}), compressjson.ErrMarshalJSON)
+	assert.Zero(t, c.Stats().Entries)
+}
+
+// TestMemCacheGetError is the table-driven test for stored entries that cannot be decoded.
+// Get must report them as found and wrap the sentinel error of the failing stage.
+func TestMemCacheGetError(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemCache[profile](1024)
+
+	wrongType, err := c.zstdTranscoder.Compress([]byte(`"not a profile"`))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "Corrupted frame", data: []byte("not zstd"), wantErr: compressjson.ErrDecompress},
+		{name: "Wrong JSON type", data: wrongType, wantErr: compressjson.ErrUnmarshalJSON},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c.mu.Lock()
+			c.entries.Add(tt.name, memEntry{data: tt.data}, int64(len(tt.data)))
+			c.mu.Unlock()
+
+			_, found, err := c.Get(tt.name)
+			assert.True(t, found)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryItem is a single value held by MemoryBackend.
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryBackend is an in-memory Backend intended for tests and single-process deployments.
+// Expired entries are removed lazily when they are read. It is safe for concurrent use.
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	items map[string]memoryItem
+	now   func() time.Time
+}
+
+// NewMemoryBackend returns an empty in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{items: make(map[string]memoryItem), now: time.Now}
+}
+
+// Get implements Backend. The returned slice is a copy and may be modified by the caller.
+func (b *MemoryBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	b.mu.RLock()
+	item, ok := b.items[key]
+	b.mu.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !item.expiresAt.IsZero() && !b.now().Before(item.expiresAt) {
+		b.mu.Lock()
+		if current, ok := b.items[key]; ok && current.expiresAt.Equal(item.expiresAt) {
+			delete(b.items, key)
+		}
+		b.mu.Unlock()
+
+		return nil, false, nil
+	}
+
+	return append([]byte(nil), item.value...), true, nil
+}
+
+// Set implements Backend. The value is copied, so the caller may reuse val afterwards.
+func (b *MemoryBackend) Set(_ context.Context, key string, val []byte, ttl time.Duration) error {
+	item := memoryItem{value: append([]byte(nil), val...)}
+	if ttl > 0 {
+		item.expiresAt = b.now().Add(ttl)
+	}
+
+	b.mu.Lock()
+	b.items[key] = item
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Delete implements Backend.
+func (b *MemoryBackend) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	delete(b.items, key)
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Len returns the number of stored entries, including expired entries that have not been read yet.
+func (b *MemoryBackend) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.items)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoryBackend is the table-driven test for MemoryBackend. It uses a controllable clock
+// to verify expiration, and checks that stored values are isolated from caller buffers.
+func TestMemoryBackend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2025, 8, 9, 12, 0, 0, 0, time.UTC)
+
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+
+	cases := []struct {
+		name      string
+		ttl       time.Duration
+		advance   time.Duration
+		wantFound bool
+	}{
+		{name: "No expiration", ttl: 0, advance: 24 * time.Hour, wantFound: true},
+		{name: "Not yet expired", ttl: time.Minute, advance: 59 * time.Second, wantFound: true},
+		{name: "Expired", ttl: time.Minute, advance: time.Minute, wantFound: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			value := []byte("value")
+			assert.NoError(t, backend.Set(ctx, tt.name, value, tt.ttl))
+
+			value[0] = 'X'
+			now = now.Add(tt.advance)
+
+			got, found, err := backend.Get(ctx, tt.name)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFound, found)
+
+			if tt.wantFound {
+				assert.Equal(t, []byte("value"), got, "Stored value must not alias the caller buffer")
+			}
+
+			assert.NoError(t, backend.Delete(ctx, tt.name))
+
+			_, found, err = backend.Get(ctx, tt.name)
+			assert.NoError(t, err)
+			assert.False(t, found, "Deleted key must not be found")
+		})
+	}
+
+	assert.Zero(t, backend.Len())
+}
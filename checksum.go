@@ -0,0 +1,59 @@
+package compressjson
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ChecksumMode selects the integrity checks written by Encode and verified by Decode.
+// Modes can be combined with a bitwise OR.
+type ChecksumMode uint8
+
+const (
+	// ChecksumNone writes no checksums. Corruption is then only detected when it breaks
+	// the Base64, Z - standard or JSON syntax, and may otherwise decode to a wrong value.
+	ChecksumNone ChecksumMode = 0
+
+	// ChecksumFrame ends every Z - standard frame with a checksum of its decompressed content.
+	// It costs 4 bytes per frame and is the default.
+	ChecksumFrame ChecksumMode = 1
+
+	// ChecksumCRC32C stores a CRC-32C (Castagnoli) of the compressed frame in an envelope, so corruption
+	// is detected before decompression. Values are wrapped into an envelope even with schema version zero.
+	ChecksumCRC32C ChecksumMode = 2
+)
+
+// crc32cTable is the Castagnoli table used for envelope checksums.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// payloadChecksum returns the CRC-32C of an envelope payload. Zero marks an envelope without
+// a checksum, so the rare payloads whose checksum is zero are stored without one.
+func payloadChecksum(payload []byte) uint32 {
+	return crc32.Checksum(payload, crc32cTable)
+}
+
+// verifyChecksum compares the checksum recorded in e, if any, with its payload.
+func (e *Envelope) verifyChecksum() error {
+	if e.Checksum == 0 {
+		return nil
+	}
+
+	if sum := payloadChecksum(e.Payload); sum != e.Checksum {
+		return fmt.Errorf("%w: envelope payload has CRC-32C %08x, want %08x", ErrChecksumMismatch, sum, e.Checksum)
+	}
+
+	return nil
+}
+
+// decompressError wraps a failed decompression with ErrDecompress, and additionally
+// with ErrChecksumMismatch when the content of a frame does not match its checksum.
+func decompressError(err error) error {
+	if errors.Is(err, zstd.ErrCRCMismatch) {
+		return errors.Join(ErrChecksumMismatch, ErrDecompress, err)
+	}
+
+	return errors.Join(ErrDecompress, err)
+}
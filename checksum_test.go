@@ -0,0 +1,135 @@
+package compressjson
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// corruptName decodes an encoded user, flips a bit in the stored name and encodes the result again.
+// Short values are stored uncompressed inside the frame, so the corruption keeps the frame and
+// the JSON well-formed and can only be detected by a checksum.
+func corruptName(t *testing.T, encoded string) string {
+	t.Helper()
+
+	data, err := base64Stage.Decode(encoded)
+	assert.NoError(t, err)
+
+	i := bytes.Index(data, []byte("Alice"))
+	assert.Positive(t, i)
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[i] ^= 0x01
+
+	out, err := base64Stage.Encode(corrupted)
+	assert.NoError(t, err)
+
+	return out
+}
+
+// TestChecksumModeValues pins the values of the checksum modes, which callers may persist in
+// configuration: ChecksumNone is zero and the other modes are distinct single bits.
+func TestChecksumModeValues(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ChecksumMode(0), ChecksumNone)
+	assert.Equal(t, ChecksumMode(1), ChecksumFrame)
+	assert.Equal(t, ChecksumMode(2), ChecksumCRC32C)
+}
+
+// TestChecksum is the table-driven test for WithChecksum. It verifies which checksums every mode
+// writes, that all modes decode interchangeably, and that corrupted values are rejected with
+// ErrChecksumMismatch whenever a checksum covers the corrupted bytes.
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: "Alice", Email: "alice@example.com"}
+
+	cases := []struct {
+		name             string
+		mode             ChecksumMode
+		wantFrame        bool
+		wantEnvelope     bool
+		wantCorruptValue bool
+	}{
+		{name: "None", mode: ChecksumNone, wantCorruptValue: true},
+		{name: "Frame", mode: ChecksumFrame, wantFrame: true},
+		{name: "CRC32C", mode: ChecksumCRC32C, wantEnvelope: true},
+		{name: "Frame and CRC32C", mode: ChecksumFrame | ChecksumCRC32C, wantFrame: true, wantEnvelope: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTranscoder[user](WithChecksum(tt.mode))
+
+			encoded, err := tr.Encode(value)
+			assert.NoError(t, err)
+
+			info, err := Inspect(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFrame, info.Checksum)
+			assert.Equal(t, tt.wantEnvelope, info.EnvelopeChecksum)
+			assert.Equal(t, tt.wantEnvelope, info.Envelope)
+
+			got, err := NewTranscoder[user]().Decode(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, value, got)
+
+			corrupted := corruptName(t, encoded)
+
+			got, err = tr.Decode(corrupted)
+			if tt.wantCorruptValue {
+				assert.NoError(t, err)
+				assert.Equal(t, "@lice", got.Name, "Without checksums corruption decodes silently")
+
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+			_, err = Inspect(corrupted)
+			assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+			_, err = DecodeRaw(corrupted)
+			assert.ErrorIs(t, err, ErrChecksumMismatch)
+		})
+	}
+}
+
+// TestEnvelopeChecksum verifies that Wrap records the checksum of the payload when the transcoder
+// uses ChecksumCRC32C, and that Unwrap rejects envelopes whose payload does not match it.
+func TestEnvelopeChecksum(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[user](WithChecksum(ChecksumCRC32C))
+	value := user{ID: 1, Name: "Alice"}
+
+	env, err := Wrap(tr, value)
+	assert.NoError(t, err)
+	assert.Equal(t, payloadChecksum(env.Payload), env.Checksum)
+
+	data, err := env.MarshalBinary()
+	assert.NoError(t, err)
+
+	var received Envelope
+	assert.NoError(t, received.UnmarshalBinary(data))
+	assert.Equal(t, env.Checksum, received.Checksum)
+
+	got, err := Unwrap(tr, &received)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	received.Payload[len(received.Payload)-1] ^= 0x01
+
+	_, err = Unwrap(tr, &received)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	plain, err := Wrap(NewTranscoder[user](), value)
+	assert.NoError(t, err)
+	assert.Zero(t, plain.Checksum)
+
+	// A checksum field of the wrong size is malformed.
+	bad := appendField(append([]byte(nil), envelopeMagic...), tagChecksum, []byte{1, 2})
+	assert.ErrorIs(t, received.UnmarshalBinary(bad), ErrInvalidEnvelope)
+}
@@ -0,0 +1,174 @@
+package compressjson
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidChunk reports a chunk that is malformed, duplicated or inconsistent
+	// with the other chunks of the same value.
+	ErrInvalidChunk = errors.New("invalid chunk")
+
+	// ErrMissingChunk reports that one or more chunks of a value are missing.
+	ErrMissingChunk = errors.New("missing chunk")
+
+	// ErrChecksumMismatch reports that reassembled or decoded data does not match its checksum.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// chunkSeparator separates the metadata fields of a chunk. It never occurs in
+// standard Base64, so the payload can follow the last separator verbatim.
+const chunkSeparator = "."
+
+// EncodeChunks encodes v with tr and splits the result into chunks of at most maxLen characters,
+// so that it fits size-limited carriers such as cookies, HTTP headers or queue messages.
+//
+// Every chunk has the form "<sequence>.<total>.<checksum>.<data>", where the sequence is zero-based,
+// total is the number of chunks, and the checksum is the CRC-32 of the complete encoded string in
+// hexadecimal. The metadata lets DecodeChunks detect missing, foreign and reordered parts.
+// A value that fits into a single chunk still produces one chunk with metadata.
+func EncodeChunks[T any](tr Transcoder[T], v T, maxLen int) ([]string, error) {
+	encoded, err := tr.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunkString(encoded, maxLen)
+}
+
+// chunkString splits an arbitrary string into chunks of at most maxLen characters.
+// It implements EncodeChunks after the encoding step and is shared with other carriers.
+func chunkString(encoded string, maxLen int) ([]string, error) {
+	checksum := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(encoded)))
+
+	// The header length depends on the number of chunks, which in turn depends on the room
+	// left for data. Grow the estimate until it is consistent; it converges in a few steps.
+	total := 1
+	for {
+		room := maxLen - chunkHeaderLen(total, total, checksum)
+		if room <= 0 {
+			return nil, fmt.Errorf("%w: maximum length %d leaves no room for data", ErrInvalidChunk, maxLen)
+		}
+
+		needed := (len(encoded) + room - 1) / room
+		if needed <= total {
+			return splitChunks(encoded, checksum, max(needed, 1), room), nil
+		}
+
+		total = needed
+	}
+}
+
+// chunkHeaderLen returns the length of the metadata prefix of a chunk.
+func chunkHeaderLen(seq, total int, checksum string) int {
+	return len(strconv.Itoa(seq)) + len(strconv.Itoa(total)) + len(checksum) + 3*len(chunkSeparator)
+}
+
+// splitChunks cuts encoded into total chunks of at most room data characters each.
+func splitChunks(encoded, checksum string, total, room int) []string {
+	chunks := make([]string, 0, total)
+	prefix := chunkSeparator + strconv.Itoa(total) + chunkSeparator + checksum + chunkSeparator
+
+	for seq := 0; seq < total; seq++ {
+		start := min(seq*room, len(encoded))
+		end := min(start+room, len(encoded))
+		chunks = append(chunks, strconv.Itoa(seq)+prefix+encoded[start:end])
+	}
+
+	return chunks
+}
+
+// chunk is the parsed form of a single chunk.
+type chunk struct {
+	seq      int
+	total    int
+	checksum string
+	data     string
+}
+
+// parseChunk splits a chunk into its metadata and data.
+func parseChunk(src string) (chunk, error) {
+	parts := strings.SplitN(src, chunkSeparator, 4)
+	if len(parts) != 4 {
+		return chunk{}, fmt.Errorf("%w: expected sequence, total, checksum and data", ErrInvalidChunk)
+	}
+
+	seq, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return chunk{}, fmt.Errorf("%w: sequence %q", ErrInvalidChunk, parts[0])
+	}
+
+	total, err := strconv.Atoi(parts[1])
+	if err != nil || total <= 0 || seq < 0 || seq >= total {
+		return chunk{}, fmt.Errorf("%w: sequence %q of %q", ErrInvalidChunk, parts[0], parts[1])
+	}
+
+	return chunk{seq: seq, total: total, checksum: parts[2], data: parts[3]}, nil
+}
+
+// DecodeChunks reassembles chunks produced by EncodeChunks and decodes the result with tr.
+// Chunks may be passed in any order. The error wraps ErrInvalidChunk for malformed, duplicated
+// or mismatched chunks, ErrMissingChunk when parts are missing, and ErrChecksumMismatch when
+// the reassembled string does not match the checksum recorded at encoding time.
+func DecodeChunks[T any](tr Transcoder[T], chunks []string) (T, error) {
+	var entry T
+
+	encoded, err := joinChunks(chunks)
+	if err != nil {
+		return entry, err
+	}
+
+	return tr.Decode(encoded)
+}
+
+// joinChunks validates and reassembles chunks produced by chunkString.
+// It implements DecodeChunks before the decoding step.
+func joinChunks(chunks []string) (string, error) {
+	if len(chunks) == 0 {
+		return "", fmt.Errorf("%w: no chunks", ErrMissingChunk)
+	}
+
+	parsed := make([]chunk, 0, len(chunks))
+	for _, src := range chunks {
+		c, err := parseChunk(src)
+		if err != nil {
+			return "", err
+		}
+
+		parsed = append(parsed, c)
+	}
+
+	first := parsed[0]
+	for _, c := range parsed[1:] {
+		if c.total != first.total || c.checksum != first.checksum {
+			return "", fmt.Errorf("%w: chunk %d belongs to a different value", ErrInvalidChunk, c.seq)
+		}
+	}
+
+	slices.SortFunc(parsed, func(a, b chunk) int { return a.seq - b.seq })
+
+	var sb strings.Builder
+	for i, c := range parsed {
+		if i > 0 && parsed[i-1].seq == c.seq {
+			return "", fmt.Errorf("%w: duplicate chunk %d", ErrInvalidChunk, c.seq)
+		}
+
+		sb.WriteString(c.data)
+	}
+
+	if len(parsed) != first.total {
+		return "", fmt.Errorf("%w: got %d of %d chunks", ErrMissingChunk, len(parsed), first.total)
+	}
+
+	encoded := sb.String()
+	if fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(encoded))) != first.checksum {
+		return "", ErrChecksumMismatch
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,116 @@
+package compressjson
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncodeChunks is the table-driven test for EncodeChunks. It verifies that every chunk
+// respects the maximum length, that the number of chunks matches the encoded size, and that
+// DecodeChunks restores the original value even when the chunks arrive shuffled.
+func TestEncodeChunks(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[[]user]()
+
+	// Pseudo-random names keep the payload from compressing into a single small chunk.
+	rng := rand.New(rand.NewSource(1))
+	users := make([]user, 0, 200)
+	for i := 0; i < 200; i++ {
+		name := make([]byte, 16)
+		for j := range name {
+			name[j] = byte('a' + rng.Intn(26))
+		}
+
+		users = append(users, user{ID: i, Name: string(name)})
+	}
+
+	cases := []struct {
+		name       string
+		input      []user
+		maxLen     int
+		wantChunks int
+		wantErr    bool
+	}{
+		{name: "Fits into one chunk", input: users[:1], maxLen: 4096, wantChunks: 1},
+		{name: "Cookie sized chunks", input: users, maxLen: 1024},
+		{name: "Tiny chunks", input: users[:20], maxLen: 24},
+		{name: "No room for data", input: users[:1], maxLen: 12, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := EncodeChunks(tr, tt.input, tt.maxLen)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidChunk)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			if tt.wantChunks > 0 {
+				assert.Len(t, chunks, tt.wantChunks)
+			} else {
+				assert.Greater(t, len(chunks), 1, "Large values must be split")
+			}
+
+			for _, c := range chunks {
+				assert.LessOrEqual(t, len(c), tt.maxLen, "Chunk exceeds the maximum length")
+			}
+
+			shuffled := append([]string(nil), chunks...)
+			rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+			got, err := DecodeChunks(tr, shuffled)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, got)
+		})
+	}
+}
+
+// TestDecodeChunksErrors is the table-driven test for the failure modes of DecodeChunks:
+// missing, duplicated, foreign, malformed and tampered chunks.
+func TestDecodeChunksErrors(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[user]()
+
+	chunks, err := EncodeChunks(tr, user{ID: 1, Name: strings.Repeat("Alice", 10), Email: "alice@example.com"}, 24)
+	assert.NoError(t, err)
+	assert.Greater(t, len(chunks), 3)
+
+	other, err := EncodeChunks(tr, user{ID: 2, Name: "Bob"}, 24)
+	assert.NoError(t, err)
+
+	tampered := append([]string(nil), chunks...)
+	last := tampered[len(tampered)-1]
+	tampered[len(tampered)-1] = last[:len(last)-1] + "A"
+	if tampered[len(tampered)-1] == last {
+		tampered[len(tampered)-1] = last[:len(last)-1] + "B"
+	}
+
+	cases := []struct {
+		name    string
+		chunks  []string
+		wantErr error
+	}{
+		{name: "No chunks", chunks: nil, wantErr: ErrMissingChunk},
+		{name: "Missing chunk", chunks: chunks[1:], wantErr: ErrMissingChunk},
+		{name: "Duplicated chunk", chunks: append([]string{chunks[0]}, chunks[:len(chunks)-1]...), wantErr: ErrInvalidChunk},
+		{name: "Chunk of another value", chunks: append(append([]string(nil), chunks[:len(chunks)-1]...), other[0]), wantErr: ErrInvalidChunk},
+		{name: "Malformed chunk", chunks: []string{"not a chunk"}, wantErr: ErrInvalidChunk},
+		{name: "Sequence out of range", chunks: []string{"5.2.00000000.data"}, wantErr: ErrInvalidChunk},
+		{name: "Tampered data", chunks: tampered, wantErr: ErrChecksumMismatch},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeChunks(tr, tt.chunks)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
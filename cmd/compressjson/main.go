@@ -0,0 +1,546 @@
+// Command compressjson encodes, decodes and examines values of the compressjson pipeline
+// (JSON, Z - standard, Base64) from the command line, so stored values can be debugged without
+// writing a Go program.
+//
+// Usage:
+//
+//	compressjson <command> [flags] [file]
+//
+// The commands are:
+//
+//	encode      read JSON and print the encoded string
+//	decode      read an encoded string and print its JSON
+//	inspect     read an encoded string and print its frame and envelope metadata
+//	recompress  read an encoded string and compress it again with another level
+//	bench       compare sizes and latencies of all levels on a sample JSON file
+//
+// Input is read from the named file, or from standard input when no file or "-" is given.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/spacemagneto/compressjson"
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// errUsage reports invalid command-line arguments; the details are already printed by the flag set.
+var errUsage = errors.New("usage")
+
+// commandNames lists the subcommands in the order they are documented.
+var commandNames = []string{"encode", "decode", "inspect", "recompress", "bench"}
+
+// summaries holds the one-line description of every subcommand.
+var summaries = map[string]string{
+	"encode":     "read JSON and print the encoded string",
+	"decode":     "read an encoded string and print its JSON",
+	"inspect":    "read an encoded string and print its frame and envelope metadata",
+	"recompress": "read an encoded string and compress it again with another level",
+	"bench":      "compare sizes and latencies of all levels on a sample JSON file",
+}
+
+// commands maps the name of every subcommand to its implementation.
+var commands = map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) error{
+	"encode":     runEncode,
+	"decode":     runDecode,
+	"inspect":    runInspect,
+	"recompress": runRecompress,
+	"bench":      runBench,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code: 0 on success and for -h,
+// 1 when the command fails and 2 for invalid arguments.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "compressjson: unknown command %q\n", args[0])
+		usage(stderr)
+
+		return 2
+	}
+
+	if err := cmd(args[1:], stdin, stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+
+		fmt.Fprintf(stderr, "compressjson %s: %v\n", args[0], err)
+
+		return 1
+	}
+
+	return 0
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: compressjson <command> [flags] [file]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range commandNames {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, summaries[name])
+	}
+
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "compressjson <command> -h" for the flags of a command.`)
+}
+
+// newFlagSet creates the flag set of a subcommand that reports errors to stderr.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("compressjson "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: compressjson %s [flags] [file]\n\n%s.\n\nflags:\n", name, summaries[name])
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseFlags parses args and accepts at most one positional argument, the input file.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return errUsage
+	}
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// pipelineFlags holds the flags that map to options of the pipeline.
+type pipelineFlags struct {
+	level           string
+	checksum        string
+	schemaVersion   uint
+	canonical       bool
+	maxDepth        int
+	maxStringLength int
+}
+
+// addEncodeFlags registers the flags that configure encoding.
+func (p *pipelineFlags) addEncodeFlags(fs *flag.FlagSet) {
+	fs.StringVar(&p.level, "level", "fastest", "compression level: fastest, default, better, best or a zstd level 1-22")
+	fs.StringVar(&p.checksum, "checksum", "frame", "checksums to write: none, frame, crc32c or frame+crc32c")
+	fs.UintVar(&p.schemaVersion, "schema-version", 0, "schema version recorded in the encoded value")
+	fs.BoolVar(&p.canonical, "canonical", false, "write canonical JSON (RFC 8785)")
+}
+
+// addDecodeFlags registers the flags that configure decoding.
+func (p *pipelineFlags) addDecodeFlags(fs *flag.FlagSet) {
+	fs.IntVar(&p.maxDepth, "max-depth", 0, "reject JSON nested deeper than this (0 = no limit)")
+	fs.IntVar(&p.maxStringLength, "max-string-length", 0, "reject JSON strings longer than this many bytes (0 = no limit)")
+}
+
+// options returns the pipeline options selected by the flags.
+func (p *pipelineFlags) options() ([]compressjson.Option, error) {
+	opts := []compressjson.Option{
+		compressjson.WithSchemaVersion(uint32(p.schemaVersion)),
+		compressjson.WithMaxDepth(p.maxDepth),
+		compressjson.WithMaxStringLength(p.maxStringLength),
+	}
+
+	if p.level != "" {
+		level, err := parseLevel(p.level)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, compressjson.WithCompressionLevel(level))
+	}
+
+	if p.checksum != "" {
+		mode, err := parseChecksum(p.checksum)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, compressjson.WithChecksum(mode))
+	}
+
+	if p.canonical {
+		opts = append(opts, compressjson.WithCanonicalJSON())
+	}
+
+	return opts, nil
+}
+
+// parseLevel accepts the names of the zstd encoder levels and numeric zstd levels.
+func parseLevel(s string) (zstd.EncoderLevel, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 22 {
+			return 0, fmt.Errorf("compression level %d out of range [1, 22]", n)
+		}
+
+		return zstd.EncoderLevelFromZstd(n), nil
+	}
+
+	ok, level := zstd.EncoderLevelFromString(s)
+	if !ok {
+		return 0, fmt.Errorf("unknown compression level %q", s)
+	}
+
+	return level, nil
+}
+
+// parseChecksum accepts "none" or a "+"-separated combination of "frame" and "crc32c".
+func parseChecksum(s string) (compressjson.ChecksumMode, error) {
+	if s == "none" {
+		return compressjson.ChecksumNone, nil
+	}
+
+	var mode compressjson.ChecksumMode
+	for _, name := range strings.Split(s, "+") {
+		switch name {
+		case "frame":
+			mode |= compressjson.ChecksumFrame
+		case "crc32c":
+			mode |= compressjson.ChecksumCRC32C
+		default:
+			return 0, fmt.Errorf("unknown checksum %q", name)
+		}
+	}
+
+	return mode, nil
+}
+
+// readInput reads the file named by the positional argument of fs, or stdin.
+func readInput(fs *flag.FlagSet, stdin io.Reader) ([]byte, error) {
+	if name := fs.Arg(0); name != "" && name != "-" {
+		return os.ReadFile(name)
+	}
+
+	return io.ReadAll(stdin)
+}
+
+// readEncoded reads an encoded string and strips surrounding whitespace, such as a trailing newline.
+func readEncoded(fs *flag.FlagSet, stdin io.Reader) (string, error) {
+	data, err := readInput(fs, stdin)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// runEncode implements the encode command.
+func runEncode(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var pf pipelineFlags
+
+	fs := newFlagSet("encode", stderr)
+	pf.addEncodeFlags(fs)
+	parallelBlock := fs.Int("parallel-block", 0, "encode a top-level array in blocks of this many elements on all cores (0 = off)")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := pf.options()
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid(data) {
+		return errors.New("input is not valid JSON")
+	}
+
+	var encoded string
+	if *parallelBlock > 0 {
+		var elements []json.RawMessage
+		if err = json.Unmarshal(data, &elements); err != nil {
+			return fmt.Errorf("-parallel-block requires a JSON array: %w", err)
+		}
+
+		opts = append(opts, compressjson.WithParallelBlockSize(*parallelBlock))
+		encoded, err = compressjson.NewParallelTranscoder[json.RawMessage](opts...).Encode(elements)
+	} else {
+		encoded, err = compressjson.NewTranscoder[json.RawMessage](opts...).Encode(data)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, encoded)
+
+	return err
+}
+
+// runDecode implements the decode command.
+func runDecode(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var pf pipelineFlags
+
+	fs := newFlagSet("decode", stderr)
+	pf.addDecodeFlags(fs)
+	compact := fs.Bool("compact", false, "print the JSON as stored instead of indented")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	encoded, err := readEncoded(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	raw, err := compressjson.DecodeRaw(encoded)
+	if err != nil {
+		return err
+	}
+
+	limits := lib.JSONOptions{MaxDepth: pf.maxDepth, MaxStringLength: pf.maxStringLength}
+	if _, err = lib.NewJSONTranscoderWithOptions[json.RawMessage](limits).Unmarshal(raw); err != nil {
+		return errors.Join(compressjson.ErrUnmarshalJSON, err)
+	}
+
+	if !*compact {
+		var out bytes.Buffer
+		if err = json.Indent(&out, raw, "", "  "); err != nil {
+			return errors.Join(compressjson.ErrUnmarshalJSON, err)
+		}
+
+		raw = out.Bytes()
+	}
+
+	_, err = fmt.Fprintf(stdout, "%s\n", raw)
+
+	return err
+}
+
+// runInspect implements the inspect command.
+func runInspect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("inspect", stderr)
+	asJSON := fs.Bool("json", false, "print the metadata as JSON")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	encoded, err := readEncoded(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	info, err := compressjson.Inspect(encoded)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(stdout, "%s\n", data)
+
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "encoded size\t%d\n", info.EncodedSize)
+	fmt.Fprintf(tw, "compressed size\t%d\n", info.CompressedSize)
+
+	if info.Envelope {
+		fmt.Fprintf(tw, "envelope type\t%s\n", orNone(info.Type))
+		fmt.Fprintf(tw, "schema version\t%d\n", info.SchemaVersion)
+
+		if !info.CreatedAt.IsZero() {
+			fmt.Fprintf(tw, "created at\t%s\n", info.CreatedAt.Format(time.RFC3339Nano))
+		}
+
+		if info.CorrelationID != "" {
+			fmt.Fprintf(tw, "correlation id\t%s\n", info.CorrelationID)
+		}
+	} else {
+		fmt.Fprintf(tw, "envelope\tnone\n")
+	}
+
+	fmt.Fprintf(tw, "frames\t%d\n", info.Frames)
+
+	if info.HasFrameContentSize {
+		fmt.Fprintf(tw, "frame content size\t%d\n", info.FrameContentSize)
+	} else {
+		fmt.Fprintf(tw, "frame content size\tnot declared\n")
+	}
+
+	fmt.Fprintf(tw, "window size\t%d\n", info.WindowSize)
+	fmt.Fprintf(tw, "frame checksum\t%t\n", info.Checksum)
+	fmt.Fprintf(tw, "envelope checksum\t%t\n", info.EnvelopeChecksum)
+	fmt.Fprintf(tw, "dictionary id\t%d\n", info.DictionaryID)
+
+	if info.JSONSize >= 0 {
+		fmt.Fprintf(tw, "json size\t%d\n", info.JSONSize)
+	} else {
+		fmt.Fprintf(tw, "json size\tunknown (dictionary required)\n")
+	}
+
+	return tw.Flush()
+}
+
+// orNone returns s, or "none" when s is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+
+	return s
+}
+
+// runRecompress implements the recompress command.
+func runRecompress(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("recompress", stderr)
+	levelName := fs.String("level", "best", "compression level: fastest, default, better, best or a zstd level 1-22")
+	checksumName := fs.String("checksum", "frame", "checksums to write: none, frame, crc32c or frame+crc32c")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	level, err := parseLevel(*levelName)
+	if err != nil {
+		return err
+	}
+
+	mode, err := parseChecksum(*checksumName)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := readEncoded(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	recompressed, err := compressjson.Recompress(encoded, compressjson.WithCompressionLevel(level), compressjson.WithChecksum(mode))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, recompressed)
+
+	return err
+}
+
+// runBench implements the bench command.
+func runBench(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("bench", stderr)
+	iterations := fs.Int("n", 100, "number of encodes and decodes per level")
+	levels := fs.String("levels", "fastest,default,better,best", "comma-separated compression levels to compare")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *iterations <= 0 {
+		return fmt.Errorf("-n must be positive, got %d", *iterations)
+	}
+
+	data, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid(data) {
+		return errors.New("input is not valid JSON")
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "level\tjson\tencoded\tratio\tencode/op\tdecode/op\t\n")
+
+	for _, name := range strings.Split(*levels, ",") {
+		level, err := parseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+
+		result, err := bench(data, level, *iterations)
+		if err != nil {
+			return fmt.Errorf("level %s: %w", level, err)
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%s\t%s\t\n", level, len(data), result.size,
+			float64(len(data))/float64(result.size), result.encode, result.decode)
+	}
+
+	return tw.Flush()
+}
+
+// benchResult holds the measurements of one level.
+type benchResult struct {
+	size   int
+	encode time.Duration
+	decode time.Duration
+}
+
+// bench encodes and decodes data n times with the given level and returns the encoded size
+// and the average latencies.
+func bench(data json.RawMessage, level zstd.EncoderLevel, n int) (benchResult, error) {
+	tr := compressjson.NewTranscoder[json.RawMessage](compressjson.WithCompressionLevel(level))
+
+	var result benchResult
+	var encoded string
+	var err error
+
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if encoded, err = tr.Encode(data); err != nil {
+			return result, err
+		}
+	}
+
+	result.encode = time.Since(start) / time.Duration(n)
+	result.size = len(encoded)
+
+	start = time.Now()
+	for i := 0; i < n; i++ {
+		if _, err = tr.Decode(encoded); err != nil {
+			return result, err
+		}
+	}
+
+	result.decode = time.Since(start) / time.Duration(n)
+
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// execute runs the command line args with the given standard input and returns the exit code
+// together with everything written to standard output and standard error.
+func execute(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+// TestCommands is the table-driven test for the subcommands. Every case encodes a document
+// with the given flags and checks the output of a second command applied to the result.
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	const document = `{"id":1,"name":"Alice","tags":["a","b","c"]}`
+
+	cases := []struct {
+		name       string
+		encodeArgs []string
+		args       []string
+		wantCode   int
+		wantOut    []string
+		wantErr    string
+	}{
+		{name: "Decode", args: []string{"decode"}, wantOut: []string{"{\n  \"id\": 1,\n", `"tags": [`}},
+		{name: "Decode compact", args: []string{"decode", "-compact"}, wantOut: []string{document}},
+		{name: "Decode versioned", encodeArgs: []string{"-schema-version", "3"}, args: []string{"decode", "-compact"}, wantOut: []string{document}},
+		{name: "Decode canonical", encodeArgs: []string{"-canonical"}, args: []string{"decode", "-compact"}, wantOut: []string{`{"id":1,"name":"Alice","tags":["a","b","c"]}`}},
+		{name: "Decode above depth limit", args: []string{"decode", "-max-depth", "1"}, wantCode: 1, wantErr: "depth"},
+		{name: "Inspect", encodeArgs: []string{"-schema-version", "3"}, args: []string{"inspect"}, wantOut: []string{"schema version      3", "frames              1", "json size           44"}},
+		{name: "Inspect as JSON", args: []string{"inspect", "-json"}, wantOut: []string{`"Frames": 1`, `"JSONSize": 44`}},
+		{name: "Recompress", args: []string{"recompress", "-level", "best"}, wantOut: []string{"KLUv/"}},
+		{name: "Recompress with numeric level", args: []string{"recompress", "-level", "19"}, wantOut: []string{"KLUv/"}},
+		{name: "Recompress with unknown level", args: []string{"recompress", "-level", "extreme"}, wantCode: 1, wantErr: "unknown compression level"},
+		{name: "Inspect with checksums", encodeArgs: []string{"-checksum", "frame+crc32c"}, args: []string{"inspect"}, wantOut: []string{"frame checksum      true", "envelope checksum   true"}},
+		{name: "Inspect without checksums", encodeArgs: []string{"-checksum", "none"}, args: []string{"inspect"}, wantOut: []string{"frame checksum      false", "envelope checksum   false"}},
+		{name: "Recompress with envelope checksum", args: []string{"recompress", "-checksum", "crc32c"}, wantOut: []string{"Q0pF"}},
+		{name: "Recompress with unknown checksum", args: []string{"recompress", "-checksum", "md5"}, wantCode: 1, wantErr: "unknown checksum"},
+		{name: "Encode at best level", encodeArgs: []string{"-level", "best"}, args: []string{"decode", "-compact"}, wantOut: []string{document}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, encoded, stderr := execute(t, document, append([]string{"encode"}, tt.encodeArgs...)...)
+			assert.Equal(t, 0, code, stderr)
+
+			code, stdout, stderr := execute(t, encoded, tt.args...)
+			assert.Equal(t, tt.wantCode, code, stderr)
+
+			for _, want := range tt.wantOut {
+				assert.Contains(t, stdout, want)
+			}
+
+			assert.Contains(t, stderr, tt.wantErr)
+		})
+	}
+}
+
+// TestEncodeParallel verifies that -parallel-block encodes arrays into several frames
+// and rejects other documents.
+func TestEncodeParallel(t *testing.T) {
+	t.Parallel()
+
+	code, encoded, stderr := execute(t, `[1,2,3,4,5]`, "encode", "-parallel-block", "2")
+	assert.Equal(t, 0, code, stderr)
+
+	_, stdout, _ := execute(t, encoded, "inspect")
+	assert.Contains(t, stdout, "frames              3")
+
+	_, stdout, _ = execute(t, encoded, "decode", "-compact")
+	assert.Equal(t, "[1,2,3,4,5]\n", stdout)
+
+	code, _, stderr = execute(t, `{"id":1}`, "encode", "-parallel-block", "2")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "requires a JSON array")
+}
+
+// TestBench verifies that bench reports one row per level for a sample file.
+func TestBench(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "sample.json")
+	assert.NoError(t, os.WriteFile(name, []byte(`{"items":["`+strings.Repeat("compressjson ", 100)+`"]}`), 0o600))
+
+	code, stdout, stderr := execute(t, "", "bench", "-n", "3", "-levels", "fastest,best", name)
+	assert.Equal(t, 0, code, stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "ratio")
+	assert.Contains(t, lines[1], "fastest")
+	assert.Contains(t, lines[2], "best")
+}
+
+// TestUsage is the table-driven test for invalid command lines.
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		stdin    string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "Help", args: []string{"encode", "-h"}, wantErr: "-schema-version"},
+		{name: "No command", wantCode: 2, wantErr: "usage: compressjson <command>"},
+		{name: "Unknown command", args: []string{"compress"}, wantCode: 2, wantErr: `unknown command "compress"`},
+		{name: "Unknown flag", args: []string{"decode", "-pretty"}, wantCode: 2, wantErr: "flag provided but not defined"},
+		{name: "Too many files", args: []string{"decode", "a", "b"}, wantCode: 2, wantErr: "usage: compressjson decode"},
+		{name: "Invalid JSON", stdin: "{", args: []string{"encode"}, wantCode: 1, wantErr: "not valid JSON"},
+		{name: "Invalid encoded string", stdin: "!!!", args: []string{"decode"}, wantCode: 1, wantErr: "Base64"},
+		{name: "Missing file", args: []string{"inspect", "does-not-exist"}, wantCode: 1, wantErr: "does-not-exist"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := execute(t, tt.stdin, tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stderr, tt.wantErr)
+		})
+	}
+}
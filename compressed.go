@@ -0,0 +1,82 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/goccy/go-json"
+)
+
+// Compressed wraps a value of type T so that it is stored as a compact, text-safe string
+// whenever the surrounding structure is serialized. Embedding a field such as
+//
+//	Payload compressjson.Compressed[Order] `json:"payload"`
+//
+// in an otherwise plain JSON document makes that single field a Base64 string produced by
+// the transcoder pipeline, while the rest of the document stays human-readable.
+// The zero value is ready to use and holds the zero value of T.
+type Compressed[T any] struct {
+	Value T
+}
+
+// NewCompressed returns a Compressed wrapper holding the given value.
+func NewCompressed[T any](value T) Compressed[T] {
+	return Compressed[T]{Value: value}
+}
+
+// MarshalText implements encoding.TextMarshaler by running the wrapped value through
+// the JSON, Z - standard and Base64 stages of the pipeline.
+func (c Compressed[T]) MarshalText() ([]byte, error) {
+	encoded, err := NewTranscoder[T]().Encode(c.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(encoded), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler by reversing MarshalText.
+// On failure the wrapped value is left unchanged and the pipeline error is returned.
+func (c *Compressed[T]) UnmarshalText(src []byte) error {
+	value, err := NewTranscoder[T]().Decode(string(src))
+	if err != nil {
+		return err
+	}
+
+	c.Value = value
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The wrapped value is encoded with MarshalText
+// and emitted as a JSON string.
+func (c Compressed[T]) MarshalJSON() ([]byte, error) {
+	text, err := c.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	// Standard Base64 output never contains characters that need escaping in JSON.
+	out := make([]byte, 0, len(text)+2)
+	out = append(out, '"')
+	out = append(out, text...)
+
+	return append(out, '"'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a JSON string produced by
+// MarshalJSON, or JSON null, which resets the wrapped value to the zero value of T.
+func (c *Compressed[T]) UnmarshalJSON(src []byte) error {
+	if bytes.Equal(bytes.TrimSpace(src), []byte("null")) {
+		var zero T
+		c.Value = zero
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(src, &text); err != nil {
+		return errors.Join(errors.New("compressed value must be a JSON string"), err)
+	}
+
+	return c.UnmarshalText([]byte(text))
+}
@@ -0,0 +1,98 @@
+package compressjson
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID    string   `json:"id"`
+	Items []string `json:"items"`
+	Total float64  `json:"total"`
+}
+
+type document struct {
+	Name    string            `json:"name"`
+	Payload Compressed[order] `json:"payload"`
+}
+
+// TestCompressedJSON is the table-driven test for the JSON round trip of Compressed[T].
+// It verifies that the wrapped field becomes a plain JSON string holding the pipeline output,
+// that the surrounding document stays readable, and that invalid payloads are rejected.
+func TestCompressedJSON(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		input   string
+		want    document
+		wantErr bool
+	}{
+		{name: "Null payload", input: `{"name":"empty","payload":null}`, want: document{Name: "empty"}},
+		{name: "Payload is not a string", input: `{"name":"bad","payload":42}`, wantErr: true},
+		{name: "Payload is not pipeline output", input: `{"name":"bad","payload":"!!!"}`, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got document
+			err := json.Unmarshal([]byte(tt.input), &got)
+
+			if tt.wantErr {
+				assert.Error(t, err, "Unmarshal must fail for invalid payload")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		src := document{
+			Name:    "order",
+			Payload: NewCompressed(order{ID: "ord_1", Items: []string{"a", "b"}, Total: 9.5}),
+		}
+
+		data, err := json.Marshal(src)
+		assert.NoError(t, err)
+
+		var raw map[string]any
+		assert.NoError(t, json.Unmarshal(data, &raw))
+		assert.Equal(t, "order", raw["name"], "Plain fields must stay readable")
+
+		encoded, ok := raw["payload"].(string)
+		assert.True(t, ok, "Compressed field must be marshaled as a JSON string")
+		assert.Equal(t, formatBase64ZSTD, detectFormat(encoded))
+
+		var got document
+		assert.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, src, got)
+	})
+}
+
+// TestCompressedText verifies the encoding.TextMarshaler implementation through encoding/xml,
+// which relies on MarshalText and UnmarshalText for attribute values.
+func TestCompressedText(t *testing.T) {
+	t.Parallel()
+
+	type envelope struct {
+		Payload Compressed[order] `xml:"payload,attr"`
+	}
+
+	src := envelope{Payload: NewCompressed(order{ID: "ord_2", Total: 1})}
+
+	data, err := xml.Marshal(src)
+	assert.NoError(t, err)
+
+	var got envelope
+	assert.NoError(t, xml.Unmarshal(data, &got))
+	assert.Equal(t, src, got)
+
+	var c Compressed[order]
+	assert.Error(t, c.UnmarshalText([]byte("not encoded")), "UnmarshalText must fail for invalid input")
+	assert.Zero(t, c.Value, "Value must be left unchanged on failure")
+}
@@ -0,0 +1,155 @@
+package compressjson
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// ErrInvalidDelta reports a delta that is malformed or does not belong to the given base value.
+var ErrInvalidDelta = errors.New("invalid delta")
+
+// Delta modes, stored in the first byte of every delta. Neither can start a Z - standard frame
+// or an envelope, so deltas are never mistaken for complete encoded values.
+const (
+	deltaMergePatch = 'P'
+	deltaDictionary = 'D'
+)
+
+// DeltaOption configures EncodeDelta.
+type DeltaOption func(*deltaConfig)
+
+// deltaConfig holds the settings applied by DeltaOption values.
+type deltaConfig struct {
+	dictionary bool
+}
+
+// WithDictionaryDelta makes EncodeDelta compress the complete JSON of the next value using the JSON
+// of the base value as a Z - standard dictionary, instead of compressing a merge patch. The delta
+// then describes byte-level differences, which works well for changes inside long strings or arrays
+// that a merge patch would have to replace as a whole. Applying such a delta requires exactly the
+// same base value, which ApplyDelta verifies.
+func WithDictionaryDelta() DeltaOption {
+	return func(c *deltaConfig) {
+		c.dictionary = true
+	}
+}
+
+// EncodeDelta returns a compact, text-safe description of how next differs from base.
+// By default the delta is a JSON Merge Patch (RFC 7396) between the canonical JSON forms of both
+// values, compressed with Z - standard and encoded to standard Base64. ApplyDelta reverses it.
+//
+// Merge patches replace arrays as a whole and cannot express members whose new value is null;
+// such members are removed, which decodes to the same Go value unless T distinguishes a missing
+// member from null, for example through a custom UnmarshalJSON.
+func EncodeDelta[T any](tr Transcoder[T], base, next T, opts ...DeltaOption) (string, error) {
+	var cfg deltaConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	t := jsonStagesOf(tr)
+
+	baseJSON, err := t.canonicalJSON(base)
+	if err != nil {
+		return "", err
+	}
+
+	nextJSON, err := t.canonicalJSON(next)
+	if err != nil {
+		return "", err
+	}
+
+	var delta []byte
+	if cfg.dictionary {
+		frame, err := t.standardTranscoder.CompressWithDict(nextJSON, baseJSON)
+		if err != nil {
+			return "", errors.Join(ErrCompress, err)
+		}
+
+		delta = append([]byte{deltaDictionary}, frame...)
+	} else {
+		patch, err := lib.CreateMergePatch(baseJSON, nextJSON)
+		if err != nil {
+			return "", errors.Join(ErrMarshalJSON, err)
+		}
+
+		frame, err := t.standardTranscoder.Compress(patch)
+		if err != nil {
+			return "", errors.Join(ErrCompress, err)
+		}
+
+		delta = append([]byte{deltaMergePatch}, frame...)
+	}
+
+	return t.binaryTranscoder.Encode(delta)
+}
+
+// ApplyDelta applies a delta produced by EncodeDelta to base and returns the next value.
+// Both modes are detected automatically. Dictionary deltas fail with ErrInvalidDelta when base
+// differs from the value they were computed against; merge patches apply to any base.
+func ApplyDelta[T any](tr Transcoder[T], base T, delta string) (T, error) {
+	var entry T
+	t := jsonStagesOf(tr)
+
+	src, err := t.binaryTranscoder.Decode(delta)
+	if err != nil {
+		return entry, errors.Join(ErrDecodeBase64, err)
+	}
+
+	if len(src) == 0 {
+		return entry, fmt.Errorf("%w: empty delta", ErrInvalidDelta)
+	}
+
+	baseJSON, err := t.canonicalJSON(base)
+	if err != nil {
+		return entry, err
+	}
+
+	var nextJSON []byte
+	switch src[0] {
+	case deltaMergePatch:
+		patch, err := t.standardTranscoder.Decompress(src[1:])
+		if err != nil {
+			return entry, decompressError(err)
+		}
+
+		if nextJSON, err = lib.MergePatch(baseJSON, patch); err != nil {
+			return entry, errors.Join(ErrInvalidDelta, err)
+		}
+	case deltaDictionary:
+		if nextJSON, err = t.standardTranscoder.DecompressWithDict(src[1:], baseJSON); err != nil {
+			return entry, errors.Join(fmt.Errorf("%w: base value does not match", ErrInvalidDelta), err)
+		}
+	default:
+		return entry, fmt.Errorf("%w: unknown mode %q", ErrInvalidDelta, src[0])
+	}
+
+	return t.decodeJSON(nextJSON)
+}
+
+// jsonStagesOf returns the pipeline stages behind tr, so that package-level functions honor its
+// JSON options. Transcoders outside this package are replaced by a default pipeline for T.
+func jsonStagesOf[T any](tr Transcoder[T]) *transcoder[T] {
+	if t, ok := tr.(*transcoder[T]); ok {
+		return t
+	}
+
+	return newTranscoder[T]()
+}
+
+// canonicalJSON marshals src to canonical JSON, the stable form deltas are computed on.
+func (t *transcoder[T]) canonicalJSON(src T) ([]byte, error) {
+	jsonBytes, err := t.jsonTranscoder.Marshal(src)
+	if err == nil && !t.opts.json.Canonical {
+		jsonBytes, err = lib.Canonicalize(jsonBytes)
+	}
+
+	if err != nil {
+		t.logEncodeFailure("json", src, err)
+		return nil, errors.Join(ErrMarshalJSON, err)
+	}
+
+	return jsonBytes, nil
+}
@@ -0,0 +1,118 @@
+package compressjson
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type snapshot struct {
+	ID      int               `json:"id"`
+	Title   string            `json:"title"`
+	Tags    []string          `json:"tags,omitempty"`
+	Meta    map[string]string `json:"meta,omitempty"`
+	Content string            `json:"content"`
+}
+
+// largeSnapshot returns a snapshot with a long, poorly compressible body.
+func largeSnapshot() snapshot {
+	doc := snapshot{ID: 1, Title: "Draft", Tags: []string{"a", "b"}, Meta: map[string]string{"owner": "alice"}}
+	for i := 0; i < 400; i++ {
+		doc.Content += fmt.Sprintf("%08x", uint32(i)*2654435761)
+	}
+
+	return doc
+}
+
+// TestEncodeDelta is the table-driven test for EncodeDelta and ApplyDelta. For both modes it
+// verifies that the delta restores the next value from the base and is much smaller than
+// encoding the next value on its own.
+func TestEncodeDelta(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[snapshot]()
+	base := largeSnapshot()
+
+	retitled := base
+	retitled.Title = "Final"
+	retitled.Meta = map[string]string{"owner": "bob"}
+	retitled.Tags = nil
+
+	edited := base
+	edited.Content = base.Content[:1000] + "inserted" + base.Content[1000:]
+
+	// Identifiers beyond 2^53 must not be rounded by the canonical JSON deltas are computed on.
+	renumbered := base
+	renumbered.ID = 1<<60 + 1
+
+	cases := []struct {
+		name    string
+		next    snapshot
+		opts    []DeltaOption
+		smaller bool
+	}{
+		{name: "Merge patch of changed fields", next: retitled, smaller: true},
+		{name: "Dictionary delta of changed fields", next: retitled, opts: []DeltaOption{WithDictionaryDelta()}, smaller: true},
+		{name: "Dictionary delta inside a long string", next: edited, opts: []DeltaOption{WithDictionaryDelta()}, smaller: true},
+		{name: "Merge patch inside a long string", next: edited},
+		{name: "Unchanged value", next: base, smaller: true},
+		{name: "Merge patch of a large integer", next: renumbered, smaller: true},
+		{name: "Dictionary delta of a large integer", next: renumbered, opts: []DeltaOption{WithDictionaryDelta()}, smaller: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, err := EncodeDelta(tr, base, tt.next, tt.opts...)
+			assert.NoError(t, err)
+
+			got, err := ApplyDelta(tr, base, delta)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.next, got)
+
+			if tt.smaller {
+				full, err := tr.Encode(tt.next)
+				assert.NoError(t, err)
+				assert.Less(t, len(delta)*10, len(full), "Delta must be much smaller than the full value")
+			}
+		})
+	}
+}
+
+// TestApplyDeltaErrors verifies that malformed deltas and dictionary deltas applied
+// to a different base are rejected.
+func TestApplyDeltaErrors(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[snapshot]()
+	base := largeSnapshot()
+	next := base
+	next.Title = "Final"
+
+	delta, err := EncodeDelta(tr, base, next, WithDictionaryDelta())
+	assert.NoError(t, err)
+
+	other := base
+	other.ID = 2
+
+	_, err = ApplyDelta(tr, other, delta)
+	assert.ErrorIs(t, err, ErrInvalidDelta)
+
+	_, err = ApplyDelta(tr, base, "")
+	assert.ErrorIs(t, err, ErrInvalidDelta)
+
+	_, err = ApplyDelta(tr, base, "WA==")
+	assert.ErrorIs(t, err, ErrInvalidDelta)
+
+	_, err = ApplyDelta(tr, base, "!!!")
+	assert.ErrorIs(t, err, ErrDecodeBase64)
+
+	// A merge patch applies to any base value.
+	patch, err := EncodeDelta(tr, base, next)
+	assert.NoError(t, err)
+
+	got, err := ApplyDelta(tr, other, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, "Final", got.Title)
+	assert.Equal(t, 2, got.ID)
+}
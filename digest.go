@@ -0,0 +1,124 @@
+package compressjson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// digestTranscoder is implemented by transcoders that can hash the JSON they marshal,
+// so that encoding and hashing share a single marshaling pass.
+type digestTranscoder[T any] interface {
+	encodeDigest(T) ([]byte, string, error)
+	digest(T) ([]byte, string, error)
+}
+
+// EncodeWithDigest encodes v with tr and returns the encoded string together with a digest
+// of the value, suitable as an HTTP ETag or a deduplication key.
+//
+// The digest is the lowercase hexadecimal hash of the canonical JSON form of v (see WithCanonicalJSON),
+// so equal values have equal digests regardless of the transcoder options, map ordering or number
+// notation. The hash is SHA-256 unless another one is configured with WithDigestHash. Transcoders
+// created by NewTranscoder marshal v only once, and the encoded string is the one Encode returns.
+func EncodeWithDigest[T any](tr Transcoder[T], v T) (encoded, digest string, err error) {
+	if dt, ok := tr.(digestTranscoder[T]); ok {
+		frame, digest, err := dt.encodeDigest(v)
+		if err != nil {
+			return "", "", err
+		}
+
+		encoded, err := base64Stage.Encode(frame)
+		if err != nil {
+			return "", "", err
+		}
+
+		return encoded, digest, nil
+	}
+
+	encoded, err = tr.Encode(v)
+	if err != nil {
+		return "", "", err
+	}
+
+	digest, err = Digest(tr, v)
+	if err != nil {
+		return "", "", err
+	}
+
+	return encoded, digest, nil
+}
+
+// Digest returns the digest EncodeWithDigest would report for v, without compressing or encoding it.
+func Digest[T any](tr Transcoder[T], v T) (string, error) {
+	if dt, ok := tr.(digestTranscoder[T]); ok {
+		_, digest, err := dt.digest(v)
+		return digest, err
+	}
+
+	// Transcoders outside this package only expose the text API, so v is marshaled here.
+	jsonBytes, err := lib.NewJSONTranscoderWithOptions[T](lib.JSONOptions{Canonical: true}).Marshal(v)
+	if err != nil {
+		return "", errors.Join(ErrMarshalJSON, err)
+	}
+
+	return hashJSON(sha256.New, jsonBytes), nil
+}
+
+// digest marshals src and hashes its canonical JSON with the configured hash function.
+// It returns the JSON as marshaled by the transcoder, which is canonical only with WithCanonicalJSON,
+// so that encodeDigest compresses the same bytes as Encode.
+func (t *transcoder[T]) digest(src T) ([]byte, string, error) {
+	jsonBytes, err := t.jsonTranscoder.Marshal(src)
+	if err != nil {
+		t.logEncodeFailure("json", src, err)
+		return nil, "", errors.Join(ErrMarshalJSON, err)
+	}
+
+	canonical := jsonBytes
+	if !t.opts.json.Canonical {
+		canonical, err = lib.Canonicalize(jsonBytes)
+		if err != nil {
+			t.logEncodeFailure("json", src, err)
+			return nil, "", errors.Join(ErrMarshalJSON, err)
+		}
+	}
+
+	newHash := t.opts.digestHash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+
+	return jsonBytes, hashJSON(newHash, canonical), nil
+}
+
+// encodeDigest is encodeBytes with the digest of the marshaled JSON as an additional result.
+func (t *transcoder[T]) encodeDigest(src T) ([]byte, string, error) {
+	jsonBytes, digest, err := t.digest(src)
+	if err != nil {
+		return nil, "", err
+	}
+
+	frame, err := t.standardTranscoder.Compress(jsonBytes)
+	if err != nil {
+		t.logEncodeFailure("zstd", src, err)
+		return nil, "", errors.Join(ErrCompress, err)
+	}
+
+	frame, err = versionedFrame(frame, t.opts.schemaVersion, t.opts.checksum&ChecksumCRC32C != 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return frame, digest, nil
+}
+
+// hashJSON returns the lowercase hexadecimal digest of src.
+func hashJSON(newHash func() hash.Hash, src []byte) string {
+	h := newHash()
+	h.Write(src)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
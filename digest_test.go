@@ -0,0 +1,104 @@
+package compressjson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/fnv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mocks "github.com/spacemagneto/compressjson/mocks"
+)
+
+// TestEncodeWithDigest is the table-driven test for EncodeWithDigest and Digest. It verifies that
+// the digest covers the canonical JSON, that the encoded string decodes back to the value, and
+// that the configured hash function is used.
+func TestEncodeWithDigest(t *testing.T) {
+	t.Parallel()
+
+	value := map[string]any{"b": 2.0, "a": "<x>"}
+	canonical := `{"a":"<x>","b":2}`
+	sum := sha256.Sum256([]byte(canonical))
+
+	fnvSum := fnv.New64a()
+	fnvSum.Write([]byte(canonical))
+
+	cases := []struct {
+		name       string
+		opts       []Option
+		wantDigest string
+	}{
+		{name: "Default SHA-256", wantDigest: hex.EncodeToString(sum[:])},
+		{name: "Canonical transcoder", opts: []Option{WithCanonicalJSON()}, wantDigest: hex.EncodeToString(sum[:])},
+		{name: "Versioned transcoder", opts: []Option{WithSchemaVersion(3)}, wantDigest: hex.EncodeToString(sum[:])},
+		{name: "Custom hash", opts: []Option{WithDigestHash(func() hash.Hash { return fnv.New64a() })}, wantDigest: hex.EncodeToString(fnvSum.Sum(nil))},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTranscoder[map[string]any](tt.opts...)
+
+			encoded, digest, err := EncodeWithDigest(tr, value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDigest, digest)
+
+			only, err := Digest(tr, value)
+			assert.NoError(t, err)
+			assert.Equal(t, digest, only)
+
+			plain, err := tr.Encode(value)
+			assert.NoError(t, err)
+			assert.Equal(t, plain, encoded, "The encoded string must not depend on the digest")
+
+			got, err := tr.Decode(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, value, got)
+		})
+	}
+}
+
+// TestDigestDistinguishesValues verifies that values differing only beyond the precision of a double,
+// such as adjacent 64-bit identifiers, have different digests and keep their exact value when encoded.
+func TestDigestDistinguishesValues(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		ID uint64 `json:"id"`
+	}
+
+	tr := NewTranscoder[record]()
+	first := record{ID: 1<<60 + 1}
+	second := record{ID: 1<<60 + 2}
+
+	encoded, digest, err := EncodeWithDigest(tr, first)
+	assert.NoError(t, err)
+
+	other, err := Digest(tr, second)
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, other)
+
+	got, err := tr.Decode(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, first, got)
+}
+
+// TestEncodeWithDigestFallback verifies that transcoders exposing only the text API
+// receive the same digest as transcoders created by NewTranscoder.
+func TestEncodeWithDigestFallback(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: "Alice"}
+
+	_, want, err := EncodeWithDigest(NewTranscoder[user](), value)
+	assert.NoError(t, err)
+
+	mock := mocks.NewMockTranscoder[user](t)
+	mock.EXPECT().Encode(value).Return("encoded", nil).Once()
+
+	encoded, digest, err := EncodeWithDigest[user](mock, value)
+	assert.NoError(t, err)
+	assert.Equal(t, "encoded", encoded)
+	assert.Equal(t, want, digest)
+}
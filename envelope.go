@@ -0,0 +1,267 @@
+package compressjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	// ContentEncodingZSTD marks an envelope payload holding a raw Z - standard frame of JSON,
+	// which is the compact form used whenever the transcoder supports it.
+	ContentEncodingZSTD = "json+zstd"
+
+	// ContentEncodingText marks an envelope payload holding the text produced by Transcoder.Encode.
+	// It is used for transcoders that only expose the text API, such as mocks or custom implementations.
+	ContentEncodingText = "json+zstd+base64"
+)
+
+// ErrInvalidEnvelope reports binary data that is not a well-formed envelope, or an envelope
+// whose payload cannot be interpreted.
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// envelopeMagic starts every binary envelope. It can never be confused with the Z - standard
+// frame magic, so decoders can tell bare frames and envelopes apart from the first bytes.
+var envelopeMagic = []byte{'C', 'J', 'E', 1}
+
+// Field tags of the binary envelope format. Every field is written as its tag, the length
+// of its value as an unsigned varint, and the value itself. Decoders skip unknown tags,
+// so new fields can be added without breaking older consumers.
+const (
+	tagType            = 1
+	tagSchemaVersion   = 2
+	tagContentEncoding = 3
+	tagCreatedAt       = 4
+	tagCorrelationID   = 5
+	tagPayload         = 6
+	tagChecksum        = 7
+)
+
+// Envelope carries an encoded payload together with the metadata consumers need to interpret it
+// without a broker-specific library: the payload type, its schema version, how it is encoded,
+// when it was created and which request or workflow it belongs to. An optional CRC-32C of the
+// payload protects it against corruption in storage or transit.
+//
+// Envelopes have a compact binary form through MarshalBinary and UnmarshalBinary,
+// and a JSON form through the struct tags, with the payload encoded as Base64.
+type Envelope struct {
+	Type            string    `json:"type,omitempty"`
+	SchemaVersion   uint32    `json:"schema_version,omitempty"`
+	ContentEncoding string    `json:"content_encoding"`
+	CreatedAt       time.Time `json:"created_at"`
+	CorrelationID   string    `json:"correlation_id,omitempty"`
+	Payload         []byte    `json:"payload"`
+	Checksum        uint32    `json:"checksum,omitempty"`
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. Empty fields are omitted.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, len(envelopeMagic)+len(e.Type)+len(e.ContentEncoding)+len(e.CorrelationID)+len(e.Payload)+32)
+	out = append(out, envelopeMagic...)
+
+	out = appendField(out, tagType, []byte(e.Type))
+	if e.SchemaVersion != 0 {
+		out = appendField(out, tagSchemaVersion, binary.AppendUvarint(nil, uint64(e.SchemaVersion)))
+	}
+
+	out = appendField(out, tagContentEncoding, []byte(e.ContentEncoding))
+	if !e.CreatedAt.IsZero() {
+		out = appendField(out, tagCreatedAt, binary.AppendVarint(nil, e.CreatedAt.UnixNano()))
+	}
+
+	out = appendField(out, tagCorrelationID, []byte(e.CorrelationID))
+	out = appendField(out, tagPayload, e.Payload)
+	if e.Checksum != 0 {
+		out = appendField(out, tagChecksum, binary.LittleEndian.AppendUint32(nil, e.Checksum))
+	}
+
+	return out, nil
+}
+
+// appendField appends a single tag-length-value field to dst, skipping empty values.
+func appendField(dst []byte, tag byte, value []byte) []byte {
+	if len(value) == 0 {
+		return dst
+	}
+
+	dst = append(dst, tag)
+	dst = binary.AppendUvarint(dst, uint64(len(value)))
+
+	return append(dst, value...)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Unknown fields are ignored.
+// The payload is copied, so src may be reused by the caller afterwards. The checksum is not
+// verified here; Unwrap and the decoders of this package verify it before using the payload.
+func (e *Envelope) UnmarshalBinary(src []byte) error {
+	if !bytes.HasPrefix(src, envelopeMagic) {
+		return fmt.Errorf("%w: missing magic number", ErrInvalidEnvelope)
+	}
+
+	*e = Envelope{}
+	rest := src[len(envelopeMagic):]
+
+	for len(rest) > 0 {
+		tag := rest[0]
+
+		length, n := binary.Uvarint(rest[1:])
+		if n <= 0 || length > uint64(len(rest)-1-n) {
+			return fmt.Errorf("%w: truncated field %d", ErrInvalidEnvelope, tag)
+		}
+
+		value := rest[1+n : 1+n+int(length)]
+		rest = rest[1+n+int(length):]
+
+		switch tag {
+		case tagType:
+			e.Type = string(value)
+		case tagSchemaVersion:
+			version, n := binary.Uvarint(value)
+			if n <= 0 || version > uint64(^uint32(0)) {
+				return fmt.Errorf("%w: schema version", ErrInvalidEnvelope)
+			}
+
+			e.SchemaVersion = uint32(version)
+		case tagContentEncoding:
+			e.ContentEncoding = string(value)
+		case tagCreatedAt:
+			nanos, n := binary.Varint(value)
+			if n <= 0 {
+				return fmt.Errorf("%w: creation time", ErrInvalidEnvelope)
+			}
+
+			e.CreatedAt = time.Unix(0, nanos).UTC()
+		case tagCorrelationID:
+			e.CorrelationID = string(value)
+		case tagPayload:
+			e.Payload = append([]byte(nil), value...)
+		case tagChecksum:
+			if len(value) != 4 {
+				return fmt.Errorf("%w: checksum", ErrInvalidEnvelope)
+			}
+
+			e.Checksum = binary.LittleEndian.Uint32(value)
+		}
+	}
+
+	return nil
+}
+
+// EnvelopeOption sets metadata on an envelope created by Wrap.
+type EnvelopeOption func(*Envelope)
+
+// WithEnvelopeType overrides the payload type name, which defaults to the Go type of the value.
+func WithEnvelopeType(name string) EnvelopeOption {
+	return func(e *Envelope) {
+		e.Type = name
+	}
+}
+
+// WithEnvelopeSchemaVersion overrides the schema version of the payload, which defaults to the
+// version configured on the transcoder with WithSchemaVersion.
+func WithEnvelopeSchemaVersion(version uint32) EnvelopeOption {
+	return func(e *Envelope) {
+		e.SchemaVersion = version
+	}
+}
+
+// WithCorrelationID records the identifier of the request or workflow the payload belongs to.
+func WithCorrelationID(id string) EnvelopeOption {
+	return func(e *Envelope) {
+		e.CorrelationID = id
+	}
+}
+
+// WithCreatedAt overrides the creation time, which defaults to the current time.
+func WithCreatedAt(t time.Time) EnvelopeOption {
+	return func(e *Envelope) {
+		e.CreatedAt = t.UTC()
+	}
+}
+
+// frameTranscoder is implemented by transcoders that expose the binary stages of the pipeline
+// in addition to the text API. Integrations use it to skip the Base64 stage where possible.
+type frameTranscoder[T any] interface {
+	encodeFrame(T) ([]byte, error)
+	decodeFrame([]byte, uint32) (T, error)
+	schemaVersion() uint32
+	checksumMode() ChecksumMode
+}
+
+// Wrap encodes v with tr and places the result into a new envelope. Transcoders created by
+// NewTranscoder store a raw Z - standard frame (ContentEncodingZSTD) and record their schema
+// version and, with ChecksumCRC32C, a checksum of the payload; other implementations store their
+// text output (ContentEncodingText).
+func Wrap[T any](tr Transcoder[T], v T, opts ...EnvelopeOption) (*Envelope, error) {
+	env := &Envelope{
+		Type:      reflect.TypeFor[T]().String(),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if ft, ok := tr.(frameTranscoder[T]); ok {
+		payload, err := ft.encodeFrame(v)
+		if err != nil {
+			return nil, err
+		}
+
+		env.ContentEncoding, env.Payload = ContentEncodingZSTD, payload
+		env.SchemaVersion = ft.schemaVersion()
+
+		if ft.checksumMode()&ChecksumCRC32C != 0 {
+			env.Checksum = payloadChecksum(payload)
+		}
+	} else {
+		encoded, err := tr.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		env.ContentEncoding, env.Payload = ContentEncodingText, []byte(encoded)
+	}
+
+	for _, opt := range opts {
+		opt(env)
+	}
+
+	return env, nil
+}
+
+// Unwrap decodes the payload of env with tr according to its content encoding. Raw frames are
+// migrated from the schema version recorded in the envelope when tr was created by NewTranscoder.
+// A recorded checksum is verified first; a mismatch is reported as ErrChecksumMismatch.
+// The envelope type is not checked; consumers that multiplex several types on one
+// channel should dispatch on env.Type before calling Unwrap.
+func Unwrap[T any](tr Transcoder[T], env *Envelope) (T, error) {
+	var entry T
+
+	if err := env.verifyChecksum(); err != nil {
+		return entry, err
+	}
+
+	switch env.ContentEncoding {
+	case ContentEncodingText:
+		return tr.Decode(string(env.Payload))
+	case ContentEncodingZSTD:
+		if ft, ok := tr.(frameTranscoder[T]); ok {
+			return ft.decodeFrame(env.Payload, env.SchemaVersion)
+		}
+
+		// Rebuild the text form produced by Encode, including the schema version.
+		frame, err := versionedFrame(env.Payload, env.SchemaVersion, env.Checksum != 0)
+		if err != nil {
+			return entry, err
+		}
+
+		encoded, err := base64Stage.Encode(frame)
+		if err != nil {
+			return entry, err
+		}
+
+		return tr.Decode(encoded)
+	default:
+		return entry, fmt.Errorf("%w: unsupported content encoding %q", ErrInvalidEnvelope, env.ContentEncoding)
+	}
+}
@@ -0,0 +1,143 @@
+package compressjson
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+
+	mocks "github.com/spacemagneto/compressjson/mocks"
+)
+
+// TestEnvelopeBinary is the table-driven test for the binary envelope form. It verifies that
+// MarshalBinary and UnmarshalBinary round trip every field, that unknown fields are skipped,
+// and that malformed input is rejected with ErrInvalidEnvelope.
+func TestEnvelopeBinary(t *testing.T) {
+	t.Parallel()
+
+	full := Envelope{
+		Type:            "orders.Created",
+		SchemaVersion:   3,
+		ContentEncoding: ContentEncodingZSTD,
+		CreatedAt:       time.Date(2025, 8, 9, 12, 0, 0, 123, time.UTC),
+		CorrelationID:   "req-42",
+		Payload:         []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00},
+	}
+
+	encoded, err := full.MarshalBinary()
+	assert.NoError(t, err)
+
+	// A field with an unknown tag appended by a newer producer.
+	extended := append(append([]byte(nil), encoded...), 99)
+	extended = binary.AppendUvarint(extended, 3)
+	extended = append(extended, "new"...)
+
+	cases := []struct {
+		name    string
+		input   []byte
+		want    Envelope
+		wantErr bool
+	}{
+		{name: "All fields", input: encoded, want: full},
+		{name: "Only magic number", input: envelopeMagic, want: Envelope{}},
+		{name: "Unknown field is skipped", input: extended, want: full},
+		{name: "Missing magic number", input: []byte("CJX\x01"), wantErr: true},
+		{name: "Bare Z - standard frame", input: []byte{0x28, 0xb5, 0x2f, 0xfd}, wantErr: true},
+		{name: "Truncated field", input: encoded[:len(encoded)-2], wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Envelope
+			err := got.UnmarshalBinary(tt.input)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidEnvelope)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestEnvelopeJSON verifies that the JSON form of an envelope round trips.
+func TestEnvelopeJSON(t *testing.T) {
+	t.Parallel()
+
+	env := Envelope{
+		Type:            "user",
+		ContentEncoding: ContentEncodingText,
+		CreatedAt:       time.Date(2025, 8, 9, 12, 0, 0, 0, time.UTC),
+		Payload:         []byte("KLUv/QQA"),
+	}
+
+	data, err := json.Marshal(env)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"payload":"S0xVdi9RUUE="`)
+
+	var got Envelope
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, env, got)
+}
+
+// TestWrapUnwrap verifies that Wrap picks the content encoding supported by the transcoder,
+// records its schema version, applies the metadata options, and that Unwrap restores the value from either encoding.
+func TestWrapUnwrap(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[user](WithSchemaVersion(2))
+	value := user{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	createdAt := time.Date(2025, 8, 9, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	env, err := Wrap(tr, value, WithCorrelationID("req-1"), WithCreatedAt(createdAt))
+	assert.NoError(t, err)
+	assert.Equal(t, "compressjson.user", env.Type)
+	assert.Equal(t, ContentEncodingZSTD, env.ContentEncoding)
+	assert.Equal(t, uint32(2), env.SchemaVersion)
+	assert.Equal(t, "req-1", env.CorrelationID)
+	assert.Equal(t, createdAt.UTC(), env.CreatedAt)
+	assert.Equal(t, zstdMagic, env.Payload[:len(zstdMagic)])
+
+	data, err := env.MarshalBinary()
+	assert.NoError(t, err)
+
+	var received Envelope
+	assert.NoError(t, received.UnmarshalBinary(data))
+
+	got, err := Unwrap(tr, &received)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	// Transcoders without the binary stages exchange text payloads.
+	encoded, err := tr.Encode(value)
+	assert.NoError(t, err)
+
+	mock := mocks.NewMockTranscoder[user](t)
+	mock.EXPECT().Encode(value).Return(encoded, nil).Once()
+	mock.EXPECT().Decode(encoded).Return(value, nil).Twice()
+
+	textEnv, err := Wrap[user](mock, value, WithEnvelopeType("users.User"))
+	assert.NoError(t, err)
+	assert.Equal(t, "users.User", textEnv.Type)
+	assert.Equal(t, ContentEncodingText, textEnv.ContentEncoding)
+
+	got, err = Unwrap(tr, textEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	got, err = Unwrap[user](mock, textEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	// A binary payload is re-encoded for transcoders that only expose the text API.
+	got, err = Unwrap[user](mock, env)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	_, err = Unwrap(tr, &Envelope{ContentEncoding: "xml"})
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+}
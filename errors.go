@@ -0,0 +1,23 @@
+package compressjson
+
+import "errors"
+
+// Sentinel errors identifying the pipeline stage that failed. Errors returned by
+// transcoders and integrations wrap one of them together with the underlying cause,
+// so callers can branch with errors.Is without parsing messages.
+var (
+	// ErrMarshalJSON reports that a value could not be marshaled to JSON.
+	ErrMarshalJSON = errors.New("failed to marshal JSON")
+
+	// ErrCompress reports that JSON data could not be compressed.
+	ErrCompress = errors.New("failed to compress with Zstd")
+
+	// ErrDecodeBase64 reports that the input is not valid standard Base64.
+	ErrDecodeBase64 = errors.New("failed to decode Base64")
+
+	// ErrDecompress reports that the input is not a valid Z - standard frame.
+	ErrDecompress = errors.New("failed to decompress Zstd")
+
+	// ErrUnmarshalJSON reports that the decompressed data could not be unmarshaled into the target type.
+	ErrUnmarshalJSON = errors.New("failed to unmarshal JSON")
+)
@@ -0,0 +1,208 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// DefaultMaxBodySize is the body size limit applied by DecodeRequest, Middleware and
+// RoundTripper when no WithMaxBodySize option is given.
+const DefaultMaxBodySize = 10 << 20
+
+var (
+	// ErrBodyTooLarge reports that an HTTP body exceeds the configured size limit,
+	// either as received or after decompression.
+	ErrBodyTooLarge = errors.New("request body too large")
+
+	// ErrUnsupportedMediaType reports that a request body uses a content type or
+	// content encoding that DecodeRequest does not understand.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// errReadBody reports that an HTTP body could not be read from the connection.
+	errReadBody = errors.New("failed to read request body")
+)
+
+// DecodeRequest reads the body of r and decodes it into a new value of type T.
+//
+// The body may be plain JSON, a raw Z - standard frame sent with "Content-Encoding: zstd",
+// or the Base64 pipeline output sent with "Content-Type: application/vnd.compressjson".
+// A request without a Content-Type is treated as JSON. Media type bodies may also hold the
+// envelope written by Encode; its checksum is verified and the document is migrated from
+// the recorded schema version. The body size is limited on the wire and after decompression,
+// see WithMaxBodySize.
+//
+// The returned error wraps ErrUnsupportedMediaType, ErrBodyTooLarge or one of the stage
+// errors; StatusCode maps it to the HTTP status that should be sent to the client.
+func DecodeRequest[T any](r *http.Request, opts ...Option) (T, error) {
+	var entry T
+
+	t := newTranscoder[T](opts...)
+	limit := maxBodySize(t.opts)
+
+	mode, err := requestEncoding(r.Header)
+	if err != nil {
+		return entry, err
+	}
+
+	if r.Body == nil {
+		return entry, errors.Join(ErrUnmarshalJSON, io.ErrUnexpectedEOF)
+	}
+
+	body, err := readBody(r.Body, limit)
+	if err != nil {
+		return entry, err
+	}
+
+	if mode == encodingIdentity {
+		return t.decodeJSON(body)
+	}
+
+	// Plain JSON and bare frames carry the current schema version; only media type bodies
+	// produced by Encode may hold an envelope recording an older one.
+	version := t.schemaVersion()
+
+	if mode == encodingMediaType {
+		body, err = t.binaryTranscoder.Decode(strings.TrimSpace(string(body)))
+		if err != nil {
+			return entry, errors.Join(ErrDecodeBase64, err)
+		}
+
+		if bytes.HasPrefix(body, envelopeMagic) {
+			body, version, err = splitVersionedFrame(body)
+			if err != nil {
+				return entry, err
+			}
+		}
+	}
+
+	jsonBytes, err := decompressLimited(body, limit)
+	if err != nil {
+		return entry, err
+	}
+
+	jsonBytes, err = t.migrate(jsonBytes, version)
+	if err != nil {
+		return entry, err
+	}
+
+	return t.decodeJSON(jsonBytes)
+}
+
+// decompressLimited decompresses an untrusted body, which may hold several concatenated frames.
+// Decompression stops as soon as the content exceeds limit, whether or not the frames declare their
+// size, so small bodies cannot expand into large allocations; the error then wraps ErrBodyTooLarge.
+func decompressLimited(body []byte, limit int64) ([]byte, error) {
+	jsonBytes, err := zstdStage.DecompressLimit(body, limit)
+	if errors.Is(err, lib.ErrSizeLimit) {
+		return nil, ErrBodyTooLarge
+	}
+
+	if err != nil {
+		return nil, decompressError(err)
+	}
+
+	return jsonBytes, nil
+}
+
+// requestEncoding validates the Content-Type and Content-Encoding headers of a request
+// and reports how its body is encoded.
+func requestEncoding(h http.Header) (bodyEncoding, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(h.Get("Content-Encoding"))); encoding {
+	case "", "identity", EncodingZSTD:
+	default:
+		return encodingIdentity, fmt.Errorf("%w: content encoding %q", ErrUnsupportedMediaType, encoding)
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType != "" && bodyEncodingOf(h) != encodingMediaType && !isJSON(h) {
+		return encodingIdentity, fmt.Errorf("%w: content type %q", ErrUnsupportedMediaType, contentType)
+	}
+
+	return bodyEncodingOf(h), nil
+}
+
+// WriteResponse encodes v and writes it to w with the given status code.
+//
+// The representation is negotiated from the Accept and Accept-Encoding headers of r:
+// clients accepting application/vnd.compressjson receive the Base64 pipeline output,
+// clients accepting the zstd content encoding receive a raw frame of the JSON document,
+// and all other clients receive plain JSON. Compressed bodies always hold a bare frame
+// of the current schema version, without the envelope Encode adds for schema versions
+// and checksums, so that Middleware and RoundTripper can read them. Nothing is written
+// if encoding fails, so the caller can still send an error response.
+func WriteResponse[T any](w http.ResponseWriter, r *http.Request, status int, v T, opts ...Option) error {
+	t := newTranscoder[T](opts...)
+	mode := negotiate(r.Header)
+
+	var body []byte
+	var err error
+
+	switch mode {
+	case encodingMediaType:
+		var frame []byte
+		var encoded string
+		frame, err = t.encodeFrame(v)
+		if err == nil {
+			encoded, err = t.binaryTranscoder.Encode(frame)
+			body = []byte(encoded)
+		}
+	case encodingZSTD:
+		body, err = t.encodeFrame(v)
+	default:
+		body, err = t.jsonTranscoder.Marshal(v)
+		if err != nil {
+			err = errors.Join(ErrMarshalJSON, err)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Accept-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	if mode == encodingMediaType {
+		header.Set("Content-Type", MediaType)
+	} else {
+		header.Set("Content-Type", mediaTypeJSON)
+	}
+
+	if mode == encodingZSTD {
+		header.Set("Content-Encoding", EncodingZSTD)
+	}
+
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+
+	return err
+}
+
+// StatusCode maps an error returned by DecodeRequest to the HTTP status code that should be
+// sent to the client: 413 for oversized bodies, 415 for unsupported media types, 400 for
+// bodies that fail any decoding stage, including envelope, checksum and migration errors,
+// and 500 for everything else. A nil error maps to 200.
+func StatusCode(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, errReadBody), errors.Is(err, ErrDecodeBase64), errors.Is(err, ErrDecompress), errors.Is(err, ErrUnmarshalJSON),
+		errors.Is(err, ErrInvalidEnvelope), errors.Is(err, ErrChecksumMismatch), errors.Is(err, ErrMigration):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,254 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecodeRequest is the table-driven test for DecodeRequest. Every case sends a request
+// through a handler built from DecodeRequest, StatusCode and WriteResponse, and checks
+// the status code and the negotiated response representation.
+func TestDecodeRequest(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, err := DecodeRequest[user](r, WithMaxBodySize(256))
+		if err != nil {
+			http.Error(w, err.Error(), StatusCode(err))
+			return
+		}
+
+		assert.NoError(t, WriteResponse(w, r, http.StatusAccepted, u))
+	})
+
+	want := user{ID: 3, Name: "Linus"}
+	plain, _ := json.Marshal(want)
+
+	encoded, err := NewTranscoder[user]().Encode(want)
+	assert.NoError(t, err)
+
+	compressed, err := newTranscoder[user]().encodeBytes(want)
+	assert.NoError(t, err)
+
+	huge, err := newTranscoder[user]().encodeBytes(user{Name: strings.Repeat("x", 1024)})
+	assert.NoError(t, err)
+
+	// A streamed frame does not declare its content size: 1 MiB of spaces in a few dozen bytes.
+	var streamed bytes.Buffer
+	w, err := zstdStage.NewWriter(&streamed)
+	assert.NoError(t, err)
+	_, err = w.Write(append(plain, bytes.Repeat([]byte(" "), 1<<20)...))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Less(t, streamed.Len(), 256, "The stream must pass the limit on the wire")
+
+	// The document split across two concatenated frames, once within and once above the limit.
+	concatenated := func(padding int) string {
+		first, err := zstdStage.Compress(append(plain[:len(plain)-1:len(plain)-1], bytes.Repeat([]byte(" "), padding)...))
+		assert.NoError(t, err)
+
+		second, err := zstdStage.Compress(append(bytes.Repeat([]byte(" "), padding), '}'))
+		assert.NoError(t, err)
+
+		assert.Less(t, len(first)+len(second), 256, "The frames must pass the limit on the wire")
+
+		return string(first) + string(second)
+	}
+
+	cases := []struct {
+		name            string
+		body            string
+		header          map[string]string
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "Plain JSON", body: string(plain), header: map[string]string{"Content-Type": mediaTypeJSON}, wantStatus: http.StatusAccepted, wantContentType: mediaTypeJSON},
+		{name: "No content type", body: string(plain), wantStatus: http.StatusAccepted, wantContentType: mediaTypeJSON},
+		{name: "Media type", body: encoded, header: map[string]string{"Content-Type": MediaType, "Accept": MediaType}, wantStatus: http.StatusAccepted, wantContentType: MediaType},
+		{name: "Zstd encoding", body: string(compressed), header: map[string]string{"Content-Encoding": EncodingZSTD, "Accept-Encoding": EncodingZSTD}, wantStatus: http.StatusAccepted, wantContentType: mediaTypeJSON},
+		{name: "Unsupported content type", body: "id=1", header: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "Unsupported content encoding", body: string(plain), header: map[string]string{"Content-Encoding": "br"}, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "Body too large on the wire", body: string(plain) + strings.Repeat(" ", 512), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Body too large after decompression", body: string(huge), header: map[string]string{"Content-Encoding": EncodingZSTD}, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Concatenated frames", body: concatenated(16), header: map[string]string{"Content-Encoding": EncodingZSTD}, wantStatus: http.StatusAccepted, wantContentType: mediaTypeJSON},
+		{name: "Concatenated frames too large after decompression", body: concatenated(200), header: map[string]string{"Content-Encoding": EncodingZSTD}, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Undeclared size too large after decompression", body: streamed.String(), header: map[string]string{"Content-Encoding": EncodingZSTD}, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Invalid JSON", body: `{"id":`, wantStatus: http.StatusBadRequest},
+		{name: "Invalid Base64", body: "!!!", header: map[string]string{"Content-Type": MediaType}, wantStatus: http.StatusBadRequest},
+		{name: "Invalid frame", body: "not zstd", header: map[string]string{"Content-Encoding": EncodingZSTD}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, "Unexpected status, body: %s", rec.Body.String())
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+
+			assert.Equal(t, tt.wantContentType, rec.Header().Get("Content-Type"))
+
+			body := rec.Body.Bytes()
+			if mode := bodyEncodingOf(rec.Header()); mode != encodingIdentity {
+				body, err = decompressBody(mode, body, DefaultMaxBodySize)
+				assert.NoError(t, err)
+			}
+
+			assert.JSONEq(t, string(plain), string(body))
+		})
+	}
+}
+
+// TestHTTPRoundTripOptions is the table-driven test for the HTTP helpers with options that make
+// Encode write an envelope. Every compressed response of WriteResponse must be readable by
+// DecodeRequest with the same options and by decompressBody, which Middleware and RoundTripper use.
+func TestHTTPRoundTripOptions(t *testing.T) {
+	t.Parallel()
+
+	want := user{ID: 7, Name: "Ada", Email: "ada@example.com"}
+
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Schema version", opts: []Option{WithSchemaVersion(2), WithMigration(0, renameFullName), WithMigration(1, addEmail)}},
+		{name: "CRC32C", opts: []Option{WithChecksum(ChecksumCRC32C)}},
+		{name: "Frame and CRC32C", opts: []Option{WithChecksum(ChecksumFrame | ChecksumCRC32C)}},
+	}
+
+	for _, tt := range cases {
+		for _, accept := range [][2]string{{"Accept", MediaType}, {"Accept-Encoding", EncodingZSTD}} {
+			t.Run(tt.name+" "+accept[1], func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+				req.Header.Set(accept[0], accept[1])
+
+				rec := httptest.NewRecorder()
+				assert.NoError(t, WriteResponse(rec, req, http.StatusOK, want, tt.opts...))
+
+				mode := bodyEncodingOf(rec.Header())
+				assert.NotEqual(t, encodingIdentity, mode, "The response must be compressed")
+
+				plain, err := decompressBody(mode, rec.Body.Bytes(), DefaultMaxBodySize)
+				assert.NoError(t, err)
+
+				var got user
+				assert.NoError(t, json.Unmarshal(plain, &got))
+				assert.Equal(t, want, got)
+
+				echo := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(rec.Body.Bytes()))
+				echo.Header = rec.Header().Clone()
+
+				got, err = DecodeRequest[user](echo, tt.opts...)
+				assert.NoError(t, err)
+				assert.Equal(t, want, got)
+			})
+		}
+	}
+}
+
+// TestDecodeRequestEnvelope verifies that DecodeRequest accepts media type bodies holding the envelope
+// written by Encode, migrating them from the recorded schema version and rejecting corrupted ones.
+func TestDecodeRequestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	v1, err := NewTranscoder[user](WithSchemaVersion(1)).Encode(user{ID: 1, Name: "Alice"})
+	assert.NoError(t, err)
+
+	newer, err := NewTranscoder[user](WithSchemaVersion(3)).Encode(user{ID: 1, Name: "Alice"})
+	assert.NoError(t, err)
+
+	checked, err := NewTranscoder[user](WithSchemaVersion(2), WithChecksum(ChecksumCRC32C)).Encode(user{ID: 1, Name: "Alice"})
+	assert.NoError(t, err)
+
+	frame, err := base64Stage.Decode(checked)
+	assert.NoError(t, err)
+
+	frame[len(frame)-1] ^= 0xff
+
+	corrupted, err := base64Stage.Encode(frame)
+	assert.NoError(t, err)
+
+	opts := []Option{WithSchemaVersion(2), WithMigration(0, renameFullName), WithMigration(1, addEmail)}
+
+	cases := []struct {
+		name       string
+		body       string
+		expected   user
+		wantStatus int
+	}{
+		{name: "Older version", body: v1, expected: user{ID: 1, Name: "Alice", Email: "unknown@example.com"}, wantStatus: http.StatusOK},
+		{name: "Newer version", body: newer, wantStatus: http.StatusBadRequest},
+		{name: "Checksum", body: checked, expected: user{ID: 1, Name: "Alice"}, wantStatus: http.StatusOK},
+		{name: "Checksum mismatch", body: corrupted, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", MediaType)
+
+			got, err := DecodeRequest[user](req, opts...)
+
+			assert.Equal(t, tt.wantStatus, StatusCode(err), "Unexpected error: %v", err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// TestWriteResponseMarshalError verifies that WriteResponse leaves the response untouched
+// when the value cannot be encoded, so the caller can still report the failure.
+func TestWriteResponseMarshalError(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	err := WriteResponse(rec, req, http.StatusOK, struct{ F func() }{F: func() {}})
+
+	assert.ErrorIs(t, err, ErrMarshalJSON)
+	assert.Empty(t, rec.Header(), "No headers must be written on failure")
+	assert.Equal(t, http.StatusInternalServerError, StatusCode(err))
+}
+
+// TestStatusCode is the table-driven test for StatusCode, including wrapped errors.
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "No error", err: nil, expected: http.StatusOK},
+		{name: "Too large", err: ErrBodyTooLarge, expected: http.StatusRequestEntityTooLarge},
+		{name: "Unsupported media type", err: ErrUnsupportedMediaType, expected: http.StatusUnsupportedMediaType},
+		{name: "Base64 stage", err: errors.Join(ErrDecodeBase64, errors.New("cause")), expected: http.StatusBadRequest},
+		{name: "Zstd stage", err: errors.Join(ErrDecompress, errors.New("cause")), expected: http.StatusBadRequest},
+		{name: "JSON stage", err: errors.Join(ErrUnmarshalJSON, errors.New("cause")), expected: http.StatusBadRequest},
+		{name: "Envelope", err: fmt.Errorf("%w: truncated field 1", ErrInvalidEnvelope), expected: http.StatusBadRequest},
+		{name: "Checksum", err: fmt.Errorf("%w: envelope payload", ErrChecksumMismatch), expected: http.StatusBadRequest},
+		{name: "Migration", err: fmt.Errorf("%w: no migration from version 0", ErrMigration), expected: http.StatusBadRequest},
+		{name: "Unknown", err: errors.New("boom"), expected: http.StatusInternalServerError},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, StatusCode(tt.err))
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MediaType identifies a body holding the text-safe pipeline output: JSON compressed
+	// with Z - standard and encoded to Base64, exactly as produced by Transcoder.Encode.
+	MediaType = "application/vnd.compressjson"
+
+	// EncodingZSTD is the Content-Encoding token for a body holding a raw Z - standard
+	// frame of the JSON document, without the Base64 stage.
+	EncodingZSTD = "zstd"
+
+	// mediaTypeJSON is the media type of a plain JSON body.
+	mediaTypeJSON = "application/json"
+)
+
+// Middleware returns an http.Handler that transparently translates compressed JSON bodies.
+//
+// Incoming requests with "Content-Encoding: zstd" or "Content-Type: application/vnd.compressjson"
+// are decoded into plain JSON before next is called, so handlers only ever see application/json.
+// Bodies that cannot be decoded are rejected with 400 Bad Request, and bodies larger than the limit
+// set with WithMaxBodySize, on the wire or after decompression, with 413 Request Entity Too Large.
+//
+// JSON responses are compressed according to the client preferences: a client accepting
+// application/vnd.compressjson receives the Base64 pipeline output, a client accepting the zstd
+// content encoding receives a raw frame, and every other client receives the response unchanged.
+// Responses are buffered in memory until the handler returns.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	limit := maxBodySize(newOptions(opts))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := decodeRequestBody(r, limit); err != nil {
+			http.Error(w, err.Error(), StatusCode(err))
+			return
+		}
+
+		mode := negotiate(r.Header)
+		if mode == encodingIdentity {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rw := &responseBuffer{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		rw.flush(w, mode)
+	})
+}
+
+// bodyEncoding describes how a compressed JSON body is represented on the wire.
+type bodyEncoding uint8
+
+const (
+	encodingIdentity bodyEncoding = iota
+	encodingZSTD
+	encodingMediaType
+)
+
+// negotiate picks the response representation preferred by the client. The dedicated
+// media type wins over the content encoding because it is the more explicit opt-in.
+func negotiate(h http.Header) bodyEncoding {
+	switch {
+	case acceptsToken(h.Values("Accept"), MediaType):
+		return encodingMediaType
+	case acceptsToken(h.Values("Accept-Encoding"), EncodingZSTD):
+		return encodingZSTD
+	default:
+		return encodingIdentity
+	}
+}
+
+// acceptsToken reports whether any of the comma-separated header values lists token
+// without an explicit q=0 weight.
+func acceptsToken(values []string, token string) bool {
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			name, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || !strings.EqualFold(name, token) {
+				continue
+			}
+
+			if q, ok := params["q"]; ok {
+				if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+					continue
+				}
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// bodyEncodingOf reports how the body described by h is encoded, based on its
+// Content-Encoding and Content-Type headers.
+func bodyEncodingOf(h http.Header) bodyEncoding {
+	if strings.EqualFold(strings.TrimSpace(h.Get("Content-Encoding")), EncodingZSTD) {
+		return encodingZSTD
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type")); err == nil && mediaType == MediaType {
+		return encodingMediaType
+	}
+
+	return encodingIdentity
+}
+
+// isJSON reports whether the Content-Type header describes a JSON document,
+// including structured suffixes such as application/problem+json.
+func isJSON(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == mediaTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// maxBodySize returns the body size limit configured with WithMaxBodySize.
+func maxBodySize(o options) int64 {
+	if o.maxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+
+	return o.maxBodySize
+}
+
+// readBody reads at most limit bytes from body and fails with ErrBodyTooLarge if there are more.
+func readBody(body io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, errors.Join(errReadBody, err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+
+	return data, nil
+}
+
+// decompressBody converts a body in the given encoding back into plain JSON of at most limit bytes.
+func decompressBody(mode bodyEncoding, body []byte, limit int64) ([]byte, error) {
+	if mode == encodingMediaType {
+		decoded, err := base64Stage.Decode(string(bytes.TrimSpace(body)))
+		if err != nil {
+			return nil, errors.Join(ErrDecodeBase64, err)
+		}
+
+		// Envelopes written by Encode are accepted; their checksum is verified, but the
+		// schema version is dropped, as the plain JSON handed on cannot carry it.
+		body, _, err = splitVersionedFrame(decoded)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decompressLimited(body, limit)
+}
+
+// compressBody converts a plain JSON body into the given encoding.
+func compressBody(mode bodyEncoding, body []byte) ([]byte, error) {
+	compressed, err := zstdStage.Compress(body)
+	if err != nil {
+		return nil, errors.Join(ErrCompress, err)
+	}
+
+	if mode != encodingMediaType {
+		return compressed, nil
+	}
+
+	encoded, err := base64Stage.Encode(compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(encoded), nil
+}
+
+// setPlainJSON rewrites the headers of a decoded body so that it describes plain JSON
+// of the given length.
+func setPlainJSON(h http.Header, mode bodyEncoding, length int) {
+	h.Del("Content-Encoding")
+	h.Set("Content-Length", strconv.Itoa(length))
+
+	if mode == encodingMediaType {
+		h.Set("Content-Type", mediaTypeJSON)
+	}
+}
+
+// decodeRequestBody replaces a compressed request body with its plain JSON content
+// and rewrites the request headers accordingly. Requests without a compressed body are left untouched.
+func decodeRequestBody(r *http.Request, limit int64) error {
+	mode := bodyEncodingOf(r.Header)
+	if mode == encodingIdentity || r.Body == nil {
+		return nil
+	}
+
+	body, err := readBody(r.Body, limit)
+	_ = r.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	jsonBytes, err := decompressBody(mode, body, limit)
+	if err != nil {
+		return err
+	}
+
+	setPlainJSON(r.Header, mode, len(jsonBytes))
+	r.Body = io.NopCloser(bytes.NewReader(jsonBytes))
+	r.ContentLength = int64(len(jsonBytes))
+
+	return nil
+}
+
+// responseBuffer is an http.ResponseWriter that captures the status, headers and body
+// written by a handler so that the body can be compressed as a whole afterwards.
+type responseBuffer struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (b *responseBuffer) Header() http.Header { return b.header }
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if !b.wroteHeader {
+		b.WriteHeader(http.StatusOK)
+	}
+
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+
+	b.status = status
+	b.wroteHeader = true
+}
+
+// flush writes the captured response to w, compressing JSON bodies in the given encoding.
+// Non-JSON bodies, empty bodies and bodies already carrying a Content-Encoding are copied verbatim.
+func (b *responseBuffer) flush(w http.ResponseWriter, mode bodyEncoding) {
+	header := w.Header()
+	for key, values := range b.header {
+		header[key] = values
+	}
+
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Accept-Encoding")
+
+	body := b.body.Bytes()
+	if b.body.Len() > 0 && isJSON(b.header) && b.header.Get("Content-Encoding") == "" {
+		if compressed, err := compressBody(mode, body); err == nil {
+			body = compressed
+
+			if mode == encodingMediaType {
+				header.Set("Content-Type", MediaType)
+			} else {
+				header.Set("Content-Encoding", EncodingZSTD)
+			}
+
+			header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+	}
+
+	w.WriteHeader(b.status)
+	_, _ = w.Write(body)
+}
+
+// RoundTripper is an http.RoundTripper that compresses outgoing JSON request bodies
+// with Z - standard and transparently decodes compressed responses, mirroring Middleware
+// on the client side.
+type RoundTripper struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+// NewRoundTripper wraps base so that JSON request bodies are sent with "Content-Encoding: zstd"
+// and zstd or application/vnd.compressjson responses are returned to the caller as plain JSON.
+// Compressed responses larger than the limit set with WithMaxBodySize, on the wire or after
+// decompression, fail with an error wrapping ErrBodyTooLarge. If base is nil, http.DefaultTransport is used.
+func NewRoundTripper(base http.RoundTripper, opts ...Option) *RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &RoundTripper{base: base, limit: maxBodySize(newOptions(opts))}
+}
+
+// RoundTrip implements http.RoundTripper. The original request is never modified;
+// a clone is sent when headers or the body need to change.
+func (t *RoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	req := r.Clone(r.Context())
+
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", EncodingZSTD)
+	}
+
+	if req.Body != nil && req.Body != http.NoBody && isJSON(req.Header) && req.Header.Get("Content-Encoding") == "" {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+
+		if err != nil {
+			return nil, errors.Join(errReadBody, err)
+		}
+
+		compressed, err := compressBody(encodingZSTD, body)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(compressed))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(compressed)), nil }
+		req.ContentLength = int64(len(compressed))
+		req.Header.Set("Content-Encoding", EncodingZSTD)
+		req.Header.Del("Content-Length")
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := bodyEncodingOf(resp.Header)
+	if mode == encodingIdentity || resp.Body == nil {
+		return resp, nil
+	}
+
+	body, err := readBody(resp.Body, t.limit)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := decompressBody(mode, body, t.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	setPlainJSON(resp.Header, mode, len(jsonBytes))
+	resp.Body = io.NopCloser(bytes.NewReader(jsonBytes))
+	resp.ContentLength = int64(len(jsonBytes))
+	resp.Uncompressed = true
+
+	return resp, nil
+}
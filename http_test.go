@@ -0,0 +1,268 @@
+package compressjson
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoHandler replies with the JSON request body it received, after checking that the
+// middleware has already turned it into plain JSON. Requests to /text reply with plain text.
+func echoHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, "plain text")
+			return
+		}
+
+		assert.Empty(t, r.Header.Get("Content-Encoding"), "Handler must not see a compressed body")
+		assert.Equal(t, mediaTypeJSON, r.Header.Get("Content-Type"), "Handler must see plain JSON")
+
+		var u user
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(u)
+	})
+}
+
+// TestMiddleware is the table-driven test for Middleware. Requests are sent with a plain
+// client, so the raw wire representation of both directions can be checked.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(Middleware(echoHandler(t)))
+	t.Cleanup(srv.Close)
+
+	want := user{ID: 7, Name: "Grace", Email: "grace@example.com"}
+	plain, _ := json.Marshal(want)
+
+	encoded, err := NewTranscoder[user]().Encode(want)
+	assert.NoError(t, err)
+
+	compressed, err := compressBody(encodingZSTD, plain)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		body            string
+		header          map[string]string
+		wantStatus      int
+		wantContentType string
+		wantEncoding    string
+	}{
+		{
+			name:            "Plain JSON in and out",
+			body:            string(plain),
+			header:          map[string]string{"Content-Type": mediaTypeJSON},
+			wantStatus:      http.StatusCreated,
+			wantContentType: mediaTypeJSON,
+		},
+		{
+			name:            "Media type in and out",
+			body:            encoded,
+			header:          map[string]string{"Content-Type": MediaType, "Accept": MediaType},
+			wantStatus:      http.StatusCreated,
+			wantContentType: MediaType,
+		},
+		{
+			name:            "Zstd content encoding in and out",
+			body:            string(compressed),
+			header:          map[string]string{"Content-Type": mediaTypeJSON, "Content-Encoding": EncodingZSTD, "Accept-Encoding": "gzip, zstd"},
+			wantStatus:      http.StatusCreated,
+			wantContentType: mediaTypeJSON,
+			wantEncoding:    EncodingZSTD,
+		},
+		{
+			name:            "Zstd refused with q=0",
+			body:            string(plain),
+			header:          map[string]string{"Content-Type": mediaTypeJSON, "Accept-Encoding": "zstd;q=0"},
+			wantStatus:      http.StatusCreated,
+			wantContentType: mediaTypeJSON,
+		},
+		{
+			name:            "Non-JSON response is not compressed",
+			path:            "/text",
+			header:          map[string]string{"Accept": MediaType},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain",
+		},
+		{
+			name:       "Corrupted request body",
+			body:       "!!! not encoded !!!",
+			header:     map[string]string{"Content-Type": MediaType},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL+tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+
+			// Disable the transport's transparent gzip handling to observe the raw response.
+			client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode, "Unexpected status, body: %s", body)
+			if tt.wantStatus == http.StatusBadRequest {
+				return
+			}
+
+			assert.Equal(t, tt.wantContentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.wantEncoding, resp.Header.Get("Content-Encoding"))
+
+			if tt.path == "/text" {
+				assert.Equal(t, "plain text", string(body))
+				return
+			}
+
+			mode := bodyEncodingOf(resp.Header)
+			if mode != encodingIdentity {
+				body, err = decompressBody(mode, body, DefaultMaxBodySize)
+				assert.NoError(t, err)
+			}
+
+			assert.JSONEq(t, string(plain), string(body))
+		})
+	}
+}
+
+// TestRoundTripper verifies that a client using RoundTripper talks to a Middleware-wrapped
+// server with compressed bodies in both directions while the caller only ever sees plain JSON.
+func TestRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	var gotEncoding string
+
+	inner := echoHandler(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		Middleware(inner).ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: NewRoundTripper(nil)}
+	want := user{ID: 9, Name: strings.Repeat("Hopper", 50)}
+	plain, _ := json.Marshal(want)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, strings.NewReader(string(plain)))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", mediaTypeJSON)
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, EncodingZSTD, gotEncoding, "Request body must be sent compressed")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"), "Response must be decoded by the round tripper")
+	assert.True(t, resp.Uncompressed)
+
+	var got user
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, want, got)
+	assert.Empty(t, req.Header.Get("Content-Encoding"), "Original request must not be modified")
+}
+
+// zstdBomb returns a streamed frame without a declared content size that expands to n spaces.
+func zstdBomb(t *testing.T, n int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := zstdStage.NewWriter(&buf)
+	assert.NoError(t, err)
+
+	_, err = w.Write(bytes.Repeat([]byte(" "), n))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+// TestMiddlewareBodyLimit is the table-driven test for the body size limit of Middleware.
+// Bodies above the limit are rejected with 413 before the handler runs, both on the wire
+// and after decompression, and the default limit applies without options.
+func TestMiddlewareBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	small, err := compressBody(encodingZSTD, []byte(`{"id":1}`))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		opts       []Option
+		body       []byte
+		wantStatus int
+	}{
+		{name: "Within limit", opts: []Option{WithMaxBodySize(256)}, body: small, wantStatus: http.StatusCreated},
+		{name: "Too large on the wire", opts: []Option{WithMaxBodySize(8)}, body: small, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Too large after decompression", opts: []Option{WithMaxBodySize(256)}, body: zstdBomb(t, 1<<20), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Default limit", body: zstdBomb(t, DefaultMaxBodySize+1), wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", mediaTypeJSON)
+			req.Header.Set("Content-Encoding", EncodingZSTD)
+
+			rec := httptest.NewRecorder()
+			Middleware(echoHandler(t), tt.opts...).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, "Unexpected status, body: %s", rec.Body.String())
+		})
+	}
+}
+
+// TestRoundTripperBodyLimit verifies that RoundTripper rejects compressed responses that expand
+// beyond the limit with ErrBodyTooLarge.
+func TestRoundTripperBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	bomb := zstdBomb(t, 1<<20)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		w.Header().Set("Content-Encoding", EncodingZSTD)
+		_, _ = w.Write(bomb)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: NewRoundTripper(nil, WithMaxBodySize(256))}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, http.NoBody)
+	assert.NoError(t, err)
+
+	resp, err := client.Do(req)
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+}
@@ -0,0 +1,168 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Info describes an encoded value as reported by Inspect.
+type Info struct {
+	// EncodedSize is the length of the Base64 text.
+	EncodedSize int
+	// CompressedSize is the number of bytes after Base64 decoding, including any envelope.
+	CompressedSize int
+
+	// Envelope reports whether the frames are wrapped in a binary envelope, as written by
+	// versioned transcoders, polymorphic transcoders and Envelope.MarshalBinary.
+	Envelope bool
+	// Type is the type name recorded in the envelope, if any.
+	Type string
+	// SchemaVersion is the schema version of the value; it is zero for bare frames.
+	SchemaVersion uint32
+	// CreatedAt and CorrelationID are the envelope metadata, if any.
+	CreatedAt     time.Time
+	CorrelationID string
+	// EnvelopeChecksum reports whether the envelope records a CRC-32C of its payload,
+	// which Inspect verifies.
+	EnvelopeChecksum bool
+
+	// Frames is the number of Z - standard frames, excluding skippable frames. It is greater than
+	// one for values written by NewParallelTranscoder.
+	Frames int
+	// FrameContentSize is the total uncompressed size declared by the frame headers. It is only
+	// valid when HasFrameContentSize is set, which requires every frame to declare its size.
+	FrameContentSize    uint64
+	HasFrameContentSize bool
+	// WindowSize is the largest window a decoder needs to keep in memory.
+	WindowSize uint64
+	// Checksum reports whether every frame ends with a content checksum.
+	Checksum bool
+	// DictionaryID is the ID of the dictionary the frames require, or zero.
+	DictionaryID uint32
+
+	// JSONSize is the size of the decompressed JSON. It is -1 when the frames require a dictionary
+	// and were therefore not decompressed.
+	JSONSize int
+}
+
+// Inspect reports metadata about a string produced by Encode without unmarshalling the value,
+// which helps to identify unknown or suspicious inputs. It parses the Base64 text, any envelope
+// and the Z - standard frame headers, and decompresses the frames to measure the JSON. Checksums are
+// verified on the way, so corrupted values fail with an error wrapping ErrChecksumMismatch.
+// Deltas produced by EncodeDelta are not supported.
+func Inspect(s string) (Info, error) {
+	info := Info{EncodedSize: len(s), JSONSize: -1}
+
+	compressedBytes, err := base64Stage.Decode(s)
+	if err != nil {
+		return info, errors.Join(ErrDecodeBase64, err)
+	}
+
+	info.CompressedSize = len(compressedBytes)
+
+	payload := compressedBytes
+	if bytes.HasPrefix(compressedBytes, envelopeMagic) {
+		var env Envelope
+		if err = env.UnmarshalBinary(compressedBytes); err != nil {
+			return info, err
+		}
+
+		if env.ContentEncoding != ContentEncodingZSTD {
+			return info, fmt.Errorf("%w: unsupported content encoding %q", ErrInvalidEnvelope, env.ContentEncoding)
+		}
+
+		info.Envelope = true
+		info.Type = env.Type
+		info.SchemaVersion = env.SchemaVersion
+		info.CreatedAt = env.CreatedAt
+		info.CorrelationID = env.CorrelationID
+		info.EnvelopeChecksum = env.Checksum != 0
+		payload = env.Payload
+
+		if err = env.verifyChecksum(); err != nil {
+			return info, err
+		}
+	}
+
+	frames, err := zstdStage.SplitFrames(payload)
+	if err != nil {
+		return info, errors.Join(ErrDecompress, err)
+	}
+
+	info.HasFrameContentSize = true
+	info.Checksum = true
+
+	for _, frame := range frames {
+		header, err := zstdStage.Header(frame)
+		if err != nil {
+			return info, errors.Join(ErrDecompress, err)
+		}
+
+		if header.Skippable {
+			continue
+		}
+
+		info.Frames++
+		info.FrameContentSize += header.FrameContentSize
+		info.HasFrameContentSize = info.HasFrameContentSize && header.HasFCS
+		info.Checksum = info.Checksum && header.HasCheckSum
+
+		window := header.WindowSize
+		if header.SingleSegment {
+			window = header.FrameContentSize
+		}
+
+		info.WindowSize = max(info.WindowSize, window)
+
+		if header.DictionaryID != 0 {
+			info.DictionaryID = header.DictionaryID
+		}
+	}
+
+	if info.Frames == 0 {
+		return info, fmt.Errorf("%w: no frames", ErrDecompress)
+	}
+
+	if !info.HasFrameContentSize {
+		info.FrameContentSize = 0
+	}
+
+	if info.DictionaryID != 0 {
+		return info, nil
+	}
+
+	jsonBytes, err := zstdStage.Decompress(payload)
+	if err != nil {
+		return info, decompressError(err)
+	}
+
+	info.JSONSize = len(jsonBytes)
+
+	return info, nil
+}
+
+// DecodeRaw reverses the Base64 and compression stages of Encode and returns the stored JSON
+// without unmarshalling it. Versioned values are accepted, but no migrations are applied, so the
+// document is returned as it was written.
+func DecodeRaw(s string) (json.RawMessage, error) {
+	compressedBytes, err := base64Stage.Decode(s)
+	if err != nil {
+		return nil, errors.Join(ErrDecodeBase64, err)
+	}
+
+	frame, _, err := splitVersionedFrame(compressedBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := zstdStage.Decompress(frame)
+	if err != nil {
+		return nil, decompressError(err)
+	}
+
+	return jsonBytes, nil
+}
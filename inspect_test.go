@@ -0,0 +1,120 @@
+package compressjson
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInspect is the table-driven test for Inspect. It covers bare and versioned values,
+// multi-frame values, typed envelopes, dictionary frames and invalid input.
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: "Alice", Email: "alice@example.com", Age: 30}
+	jsonBytes, err := json.Marshal(value)
+	assert.NoError(t, err)
+
+	bare, err := NewTranscoder[user]().Encode(value)
+	assert.NoError(t, err)
+
+	versioned, err := NewTranscoder[user](WithSchemaVersion(4)).Encode(value)
+	assert.NoError(t, err)
+
+	list := make([]int, 10)
+	parallel, err := NewParallelTranscoder[int](WithParallelBlockSize(4)).Encode(list)
+	assert.NoError(t, err)
+
+	reg := NewRegistry[event]()
+	assert.NoError(t, Register[event, userCreated](reg, "user.created"))
+
+	typed, err := NewPolymorphicTranscoder(reg).Encode(userCreated{ID: 1})
+	assert.NoError(t, err)
+
+	dictFrame, err := zstdStage.CompressWithDict(jsonBytes, []byte(`{"id":0,"name":"","email":"","age":0}`))
+	assert.NoError(t, err)
+
+	withDict, err := base64Stage.Encode(dictFrame)
+	assert.NoError(t, err)
+
+	textEnv, err := (&Envelope{ContentEncoding: ContentEncodingText, Payload: []byte(bare)}).MarshalBinary()
+	assert.NoError(t, err)
+
+	text, err := base64Stage.Encode(textEnv)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		input   string
+		check   func(t *testing.T, info Info)
+		wantErr error
+	}{
+		{name: "Bare frame", input: bare, check: func(t *testing.T, info Info) {
+			assert.Equal(t, len(bare), info.EncodedSize)
+			assert.False(t, info.Envelope)
+			assert.Equal(t, 1, info.Frames)
+			assert.True(t, info.Checksum)
+			if info.HasFrameContentSize {
+				assert.Equal(t, uint64(len(jsonBytes)), info.FrameContentSize)
+			}
+			assert.Equal(t, len(jsonBytes), info.JSONSize)
+			assert.NotZero(t, info.WindowSize)
+			assert.Zero(t, info.DictionaryID)
+		}},
+		{name: "Versioned value", input: versioned, check: func(t *testing.T, info Info) {
+			assert.True(t, info.Envelope)
+			assert.Equal(t, uint32(4), info.SchemaVersion)
+			assert.Equal(t, len(jsonBytes), info.JSONSize)
+		}},
+		{name: "Several frames", input: parallel, check: func(t *testing.T, info Info) {
+			assert.Equal(t, 3, info.Frames)
+			assert.Equal(t, len("[0,0,0,0,0,0,0,0,0,0]"), info.JSONSize)
+		}},
+		{name: "Typed envelope", input: typed, check: func(t *testing.T, info Info) {
+			assert.True(t, info.Envelope)
+			assert.Equal(t, "user.created", info.Type)
+		}},
+		{name: "Dictionary frame", input: withDict, check: func(t *testing.T, info Info) {
+			assert.NotZero(t, info.DictionaryID)
+			assert.Equal(t, -1, info.JSONSize)
+		}},
+		{name: "Invalid Base64", input: "!!!", wantErr: ErrDecodeBase64},
+		{name: "Not a frame", input: "aGVsbG8gd29ybGQ=", wantErr: ErrDecompress},
+		{name: "Text envelope", input: text, wantErr: ErrInvalidEnvelope},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Inspect(tt.input)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.input), info.EncodedSize)
+			tt.check(t, info)
+		})
+	}
+}
+
+// TestDecodeRaw verifies that DecodeRaw returns the stored JSON of bare and versioned values.
+func TestDecodeRaw(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: "Alice"}
+
+	for _, tr := range []Transcoder[user]{NewTranscoder[user](), NewTranscoder[user](WithSchemaVersion(2))} {
+		encoded, err := tr.Encode(value)
+		assert.NoError(t, err)
+
+		raw, err := DecodeRaw(encoded)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":1,"name":"Alice"}`, string(raw))
+	}
+
+	_, err := DecodeRaw("KLUv/QA=")
+	assert.ErrorIs(t, err, ErrDecompress)
+}
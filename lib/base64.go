@@ -1,6 +1,9 @@
 package lib
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+	"io"
+)
 
 // Base64Transcoder provides a straightforward implementation of Base64 encoding and decoding
 // using Go's standard library encoding/base64 package with the standard padding rules (RFC 4648).
@@ -26,3 +29,15 @@ func (t *Base64Transcoder) Encode(src []byte) (string, error) {
 func (t *Base64Transcoder) Decode(src string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(src)
 }
+
+// NewEncoder returns a writer that Base64-encodes everything written to it into w using the
+// standard encoding. The caller must Close the returned writer to flush the final partial block;
+// closing it does not close w.
+func (t *Base64Transcoder) NewEncoder(w io.Writer) io.WriteCloser {
+	return base64.NewEncoder(base64.StdEncoding, w)
+}
+
+// NewDecoder returns a reader that decodes standard Base64 read from r.
+func (t *Base64Transcoder) NewDecoder(r io.Reader) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, r)
+}
@@ -0,0 +1,202 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/goccy/go-json"
+)
+
+// Canonicalize rewrites a JSON document into its canonical form, following the JSON
+// Canonicalization Scheme of RFC 8785: no insignificant whitespace, object members sorted by
+// the UTF-16 code units of their keys, numbers formatted like ECMAScript Number.prototype.toString,
+// and strings escaped minimally, without HTML escaping. Equal documents therefore always produce
+// byte-identical output, which makes the result suitable for hashing and deduplication.
+//
+// Unlike RFC 8785, integer literals are kept exactly as written, apart from negative zero, so
+// identifiers beyond 2^53 survive canonicalization. Numbers with a fraction or an exponent are
+// interpreted as IEEE 754 double precision values. Duplicate object keys keep the last value.
+func Canonicalize(src []byte) ([]byte, error) {
+	doc, err := decodeAny(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendCanonical(make([]byte, 0, len(src)), doc)
+}
+
+// appendCanonical appends the canonical form of a decoded JSON value to dst.
+func appendCanonical(dst []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case bool:
+		return strconv.AppendBool(dst, v), nil
+	case string:
+		return appendCanonicalString(dst, v), nil
+	case json.Number:
+		if isIntegerLiteral(v) {
+			if v == "-0" {
+				return append(dst, '0'), nil
+			}
+
+			return append(dst, v...), nil
+		}
+
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("json: number %s cannot be canonicalized: %w", v, err)
+		}
+
+		return appendCanonicalNumber(dst, f), nil
+	case []any:
+		dst = append(dst, '[')
+		for i, elem := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+
+			var err error
+			if dst, err = appendCanonical(dst, elem); err != nil {
+				return nil, err
+			}
+		}
+
+		return append(dst, ']'), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		slices.SortFunc(keys, compareUTF16)
+
+		dst = append(dst, '{')
+		for i, key := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+
+			dst = appendCanonicalString(dst, key)
+			dst = append(dst, ':')
+
+			var err error
+			if dst, err = appendCanonical(dst, v[key]); err != nil {
+				return nil, err
+			}
+		}
+
+		return append(dst, '}'), nil
+	default:
+		return nil, fmt.Errorf("json: unexpected value of type %T", v)
+	}
+}
+
+// compareUTF16 orders strings by their UTF-16 code units, as required by RFC 8785.
+// It only differs from byte order for characters outside the Basic Multilingual Plane.
+func compareUTF16(a, b string) int {
+	return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+}
+
+// appendCanonicalString appends s as a JSON string. Only the quotation mark, the backslash and
+// control characters are escaped; the short forms are used where JSON defines them.
+func appendCanonicalString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			if c < 0x20 {
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			} else {
+				dst = append(dst, c)
+			}
+		}
+	}
+
+	return append(dst, '"')
+}
+
+// isIntegerLiteral reports whether a JSON number has neither a fraction nor an exponent.
+// The JSON grammar rules out leading zeros, so such literals are already in canonical form.
+func isIntegerLiteral(n json.Number) bool {
+	return !strings.ContainsAny(string(n), ".eE")
+}
+
+// appendCanonicalNumber appends f formatted like ECMAScript Number.prototype.toString:
+// the shortest representation that round trips, in plain notation for decimal exponents
+// from -6 to 20 and in exponential notation otherwise.
+func appendCanonicalNumber(dst []byte, f float64) []byte {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		// JSON cannot represent NaN and infinities, and negative zero is written as 0.
+		return append(dst, '0')
+	}
+
+	if f < 0 {
+		dst = append(dst, '-')
+		f = -f
+	}
+
+	// The shortest round-trip digits and the decimal exponent, e.g. "1.2345e+02".
+	formatted := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp, _ := bytes.Cut([]byte(formatted), []byte{'e'})
+	digits := bytes.Replace(mantissa, []byte{'.'}, nil, 1)
+
+	e, _ := strconv.Atoi(string(exp))
+	n := e + 1 // position of the decimal point relative to the digits
+	k := len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		dst = append(dst, digits...)
+		for ; k < n; k++ {
+			dst = append(dst, '0')
+		}
+	case 0 < n && n <= 21:
+		dst = append(dst, digits[:n]...)
+		dst = append(dst, '.')
+		dst = append(dst, digits[n:]...)
+	case -6 < n && n <= 0:
+		dst = append(dst, '0', '.')
+		for ; n < 0; n++ {
+			dst = append(dst, '0')
+		}
+
+		dst = append(dst, digits...)
+	default:
+		dst = append(dst, digits[0])
+		if k > 1 {
+			dst = append(dst, '.')
+			dst = append(dst, digits[1:]...)
+		}
+
+		dst = append(dst, 'e')
+		if n-1 > 0 {
+			dst = append(dst, '+')
+		}
+
+		dst = strconv.AppendInt(dst, int64(n-1), 10)
+	}
+
+	return dst
+}
@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCanonicalize is the table-driven test for Canonicalize. It covers member ordering,
+// whitespace removal, string escaping and the number formatting rules of RFC 8785.
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Sorted members", input: `{"b": 1, "a": {"d": [true, null], "c": "x"}}`, want: `{"a":{"c":"x","d":[true,null]},"b":1}`},
+		{name: "UTF-16 key order", input: `{"😀": 1, "ﬁ": 2}`, want: `{"😀":1,"ﬁ":2}`},
+		{name: "No HTML escaping", input: `"<a href=\"x\">&amp;</a>"`, want: `"<a href=\"x\">&amp;</a>"`},
+		{name: "Control characters", input: `"\u0001\n\t\u001f "`, want: "\"\\u0001\\n\\t\\u001f \""},
+		{name: "Integers", input: `[0, -0, 1, 100, 1e3, 1.0, 1.50]`, want: `[0,0,1,100,1000,1,1.5]`},
+		{name: "Large and small numbers", input: `[1e21, 1e20, 123456789012345680000, 0.000001, 1e-7, -1.5e-10]`, want: `[1e+21,100000000000000000000,123456789012345680000,0.000001,1e-7,-1.5e-10]`},
+		{name: "Shortest round trip", input: `[0.1, 333333333.3333333, 9007199254740993.5]`, want: `[0.1,333333333.3333333,9007199254740994]`},
+		{name: "Large integers", input: `[9007199254740993, 18446744073709551615, -9223372036854775808, 9007199254740993.0]`, want: `[9007199254740993,18446744073709551615,-9223372036854775808,9007199254740992]`},
+		{name: "Out of range number", input: `1e400`, wantErr: true},
+		{name: "Invalid JSON", input: `{"a":`, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize([]byte(tt.input))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+// TestAppendCanonicalNumber checks number formatting against the examples of RFC 8785, appendix B.
+func TestAppendCanonicalNumber(t *testing.T) {
+	cases := []struct {
+		input float64
+		want  string
+	}{
+		{input: math.Float64frombits(0x0000000000000001), want: "5e-324"},
+		{input: math.Float64frombits(0x8000000000000000), want: "0"},
+		{input: math.Float64frombits(0x7fefffffffffffff), want: "1.7976931348623157e+308"},
+		{input: math.Float64frombits(0x4340000000000000), want: "9007199254740992"},
+		{input: math.Float64frombits(0x444b1ae4d6e2ef50), want: "1e+21"},
+		{input: math.Float64frombits(0x3eb0c6f7a0b5ed8d), want: "0.000001"},
+		{input: math.Float64frombits(0x3eb0c6f7a0b5ed8c), want: "9.999999999999997e-7"},
+		{input: math.Float64frombits(0x44b52d02c7e14af6), want: "1e+23"},
+		{input: math.Float64frombits(0xc330000000000000), want: "-4503599627370496"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(appendCanonicalNumber(nil, tt.input)))
+		})
+	}
+}
+
+// TestJSONTranscoderCanonical verifies that canonical mode produces identical output for maps
+// regardless of their construction order and for values that only differ in number notation.
+func TestJSONTranscoderCanonical(t *testing.T) {
+	transcoder := NewJSONTranscoderWithOptions[map[string]any](JSONOptions{Canonical: true})
+
+	first := map[string]any{}
+	second := map[string]any{}
+	for i := 0; i < 100; i++ {
+		first[string(rune('A'+i%26))+string(rune('a'+i/26))] = i
+	}
+	for i := 99; i >= 0; i-- {
+		second[string(rune('A'+i%26))+string(rune('a'+i/26))] = float64(i)
+	}
+
+	a, err := transcoder.Marshal(first)
+	assert.NoError(t, err)
+
+	b, err := transcoder.Marshal(second)
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(a), string(b))
+
+	html, err := transcoder.Marshal(map[string]any{"html": "<b>&</b>"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"html":"<b>&</b>"}`, string(html))
+
+	type record struct {
+		ID uint64 `json:"id"`
+	}
+
+	ids := NewJSONTranscoderWithOptions[record](JSONOptions{Canonical: true})
+	want := record{ID: math.MaxUint64 - 1}
+
+	out, err := ids.Marshal(want)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":18446744073709551614}`, string(out))
+
+	got, err := ids.Unmarshal(out)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got, "Integers beyond 2^53 must survive canonicalization")
+}
@@ -1,11 +1,56 @@
 package lib
 
-import "github.com/goccy/go-json"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrMaxDepth reports JSON input nested deeper than JSONOptions.MaxDepth.
+	ErrMaxDepth = errors.New("json: maximum nesting depth exceeded")
+
+	// ErrMaxStringLength reports a JSON string longer than JSONOptions.MaxStringLength.
+	ErrMaxStringLength = errors.New("json: maximum string length exceeded")
+)
+
+// JSONOptions configures how a JSONTranscoder validates and interprets its input.
+// The zero value keeps the lax defaults of encoding/json.
+type JSONOptions struct {
+	// DisallowUnknownFields rejects objects with keys that do not match any field of the target.
+	DisallowUnknownFields bool
+
+	// UseNumber decodes numbers inside interface values as json.Number instead of float64,
+	// which keeps large integers and exact decimal representations intact.
+	UseNumber bool
+
+	// MaxDepth limits the nesting of objects and arrays. Zero means unlimited.
+	MaxDepth int
+
+	// MaxStringLength limits the length of every string, including object keys, in bytes
+	// as they appear in the input, escape sequences included. Zero means unlimited.
+	MaxStringLength int
+
+	// Canonical makes Marshal produce canonical JSON as described by Canonicalize,
+	// so that equal values always marshal to byte-identical output.
+	Canonical bool
+}
+
+// lax reports whether the options leave decoding at the encoding/json defaults.
+func (o JSONOptions) lax() bool {
+	return !o.DisallowUnknownFields && !o.UseNumber && o.MaxDepth <= 0 && o.MaxStringLength <= 0
+}
 
 // JSONTranscoder provides a generic, stateless wrapper for JSON serialization and deserialization.
 // It enables type-safe marshaling and unmarshalling of arbitrary Go values while preserving
 // full compatibility with standard JSON encoding rules (struct tags, custom Marshal/Unmarshal, etc.).
-type JSONTranscoder[T any] struct{}
+// Its only configuration is the immutable set of JSONOptions it was created with.
+type JSONTranscoder[T any] struct {
+	opts JSONOptions
+}
 
 // NewJSONTranscoder creates a new instance of JSONTranscoder for the specified type T.
 // Because the implementation has no internal state, the returned instance can be safely
@@ -15,10 +60,22 @@ func NewJSONTranscoder[T any]() *JSONTranscoder[T] {
 	return &JSONTranscoder[T]{}
 }
 
+// NewJSONTranscoderWithOptions creates a JSONTranscoder for T that validates its input
+// according to opts. Like the default transcoder it is immutable and safe for concurrent use.
+func NewJSONTranscoderWithOptions[T any](opts JSONOptions) *JSONTranscoder[T] {
+	return &JSONTranscoder[T]{opts: opts}
+}
+
 // Marshal converts the given value of type T into its JSON byte representation.
 // The operation respects all standard JSON marshaling semantics and produces valid UTF-8 output.
+// In canonical mode the output is additionally rewritten by Canonicalize.
 func (t *JSONTranscoder[T]) Marshal(src T) ([]byte, error) {
-	return json.Marshal(src)
+	out, err := json.Marshal(src)
+	if err != nil || !t.opts.Canonical {
+		return out, err
+	}
+
+	return Canonicalize(out)
 }
 
 // Unmarshal parses JSON data from the provided byte slice and populates a new value of type T.
@@ -26,6 +83,71 @@ func (t *JSONTranscoder[T]) Marshal(src T) ([]byte, error) {
 // with the parsing or assignment error.
 func (t *JSONTranscoder[T]) Unmarshal(src []byte) (T, error) {
 	var entry T
-	err := json.Unmarshal(src, &entry)
-	return entry, err
+
+	if t.opts.lax() {
+		err := json.Unmarshal(src, &entry)
+		return entry, err
+	}
+
+	if err := checkLimits(src, t.opts.MaxDepth, t.opts.MaxStringLength); err != nil {
+		return entry, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	if t.opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if t.opts.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(&entry); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	// Unmarshal rejects trailing data after the value; a decoder would silently leave it unread.
+	if _, err := dec.Token(); err != io.EOF {
+		var zero T
+		return zero, fmt.Errorf("json: invalid data after top-level value at offset %d", dec.InputOffset())
+	}
+
+	return entry, nil
+}
+
+// checkLimits scans src once and reports whether it exceeds the nesting depth or string length
+// limits. It runs before unmarshalling so that hostile input is rejected before any allocation.
+// The scan is not a full validator; syntax errors are left to the decoder.
+func checkLimits(src []byte, maxDepth, maxStringLength int) error {
+	if maxDepth <= 0 && maxStringLength <= 0 {
+		return nil
+	}
+
+	depth := 0
+
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '{', '[':
+			depth++
+			if maxDepth > 0 && depth > maxDepth {
+				return fmt.Errorf("%w: limit is %d at offset %d", ErrMaxDepth, maxDepth, i)
+			}
+		case '}', ']':
+			depth--
+		case '"':
+			start := i
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+
+			if maxStringLength > 0 && i-start-1 > maxStringLength {
+				return fmt.Errorf("%w: limit is %d at offset %d", ErrMaxStringLength, maxStringLength, start)
+			}
+		}
+	}
+
+	return nil
 }
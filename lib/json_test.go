@@ -3,6 +3,7 @@ package lib
 import (
 	"testing"
 
+	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -129,3 +130,56 @@ func TestJSONTranscoderUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+// TestJSONTranscoderUnmarshalWithOptions is the table-driven test for the validation options
+// of JSONTranscoder[T]. It verifies that every limit rejects offending input, accepts input at
+// the limit, and that UseNumber preserves numbers that do not fit into a float64.
+func TestJSONTranscoderUnmarshalWithOptions(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    JSONOptions
+		input   string
+		want    map[string]any
+		wantErr error
+	}{
+		{name: "Unknown fields allowed by default", opts: JSONOptions{MaxDepth: 8}, input: `{"extra":1}`, want: map[string]any{"extra": float64(1)}},
+		{name: "Use number", opts: JSONOptions{UseNumber: true}, input: `{"n":12345678901234567890}`, want: map[string]any{"n": json.Number("12345678901234567890")}},
+		{name: "Depth at the limit", opts: JSONOptions{MaxDepth: 2}, input: `{"a":[1]}`, want: map[string]any{"a": []any{float64(1)}}},
+		{name: "Depth above the limit", opts: JSONOptions{MaxDepth: 2}, input: `{"a":[[1]]}`, wantErr: ErrMaxDepth},
+		{name: "Brackets inside strings are ignored", opts: JSONOptions{MaxDepth: 1}, input: `{"a":"[[{{\"]]"}`, want: map[string]any{"a": `[[{{"]]`}},
+		{name: "String at the limit", opts: JSONOptions{MaxStringLength: 4}, input: `{"a":"abcd"}`, want: map[string]any{"a": "abcd"}},
+		{name: "String above the limit", opts: JSONOptions{MaxStringLength: 4}, input: `{"a":"abcde"}`, wantErr: ErrMaxStringLength},
+		{name: "Key above the limit", opts: JSONOptions{MaxStringLength: 4}, input: `{"abcde":1}`, wantErr: ErrMaxStringLength},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewJSONTranscoderWithOptions[map[string]any](tt.opts).Unmarshal([]byte(tt.input))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestJSONTranscoderDisallowUnknownFields verifies that unknown keys and trailing data are
+// rejected in strict mode, while known fields still decode.
+func TestJSONTranscoderDisallowUnknownFields(t *testing.T) {
+	transcoder := NewJSONTranscoderWithOptions[User](JSONOptions{DisallowUnknownFields: true})
+
+	got, err := transcoder.Unmarshal([]byte(`{"id":1,"name":"Alice"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, User{ID: 1, Name: "Alice"}, got)
+
+	_, err = transcoder.Unmarshal([]byte(`{"id":1,"role":"admin"}`))
+	assert.Error(t, err, "Unknown fields must be rejected")
+
+	_, err = transcoder.Unmarshal([]byte(`{"id":1} {"id":2}`))
+	assert.Error(t, err, "Trailing data must be rejected")
+}
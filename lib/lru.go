@@ -0,0 +1,113 @@
+package lib
+
+import "container/list"
+
+// lruEntry is a single key-value pair tracked by LRU together with its cost.
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+// LRU is a least-recently-used cache bounded by the total cost of its entries rather than by
+// their count. Callers choose what cost means: the size of an entry in bytes gives a memory
+// budget, while a constant cost of one gives a classic count-bounded cache.
+//
+// LRU is not safe for concurrent use; callers must provide their own synchronization.
+type LRU[K comparable, V any] struct {
+	capacity int64
+	cost     int64
+	order    *list.List
+	items    map[K]*list.Element
+	onEvict  func(K, V)
+}
+
+// NewLRU creates an empty cache holding entries whose total cost does not exceed capacity.
+// If onEvict is not nil, it is called for every entry removed to make room for new ones;
+// it is not called for entries removed explicitly or replaced by Add.
+func NewLRU[K comparable, V any](capacity int64, onEvict func(K, V)) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+		onEvict:  onEvict,
+	}
+}
+
+// Get returns the value stored under key and marks it as the most recently used.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*lruEntry[K, V]).value, true
+}
+
+// Add stores value under key with the given cost, replacing any previous value, and evicts
+// the least recently used entries until the total cost fits the capacity again.
+// An entry whose cost alone exceeds the capacity is not stored and Add returns false;
+// any previous value stored under key is dropped in that case as well.
+func (c *LRU[K, V]) Add(key K, value V, cost int64) bool {
+	c.Remove(key)
+
+	if cost > c.capacity {
+		return false
+	}
+
+	for c.cost+cost > c.capacity {
+		c.evictOldest()
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, cost: cost})
+	c.cost += cost
+
+	return true
+}
+
+// Remove deletes key from the cache and reports whether it was present.
+func (c *LRU[K, V]) Remove(key K) bool {
+	elem, ok := c.items[key]
+	if !ok {
+		return false
+	}
+
+	c.removeElement(elem)
+
+	return true
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRU[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Cost returns the total cost of all entries in the cache.
+func (c *LRU[K, V]) Cost() int64 {
+	return c.cost
+}
+
+// evictOldest removes the least recently used entry and notifies onEvict.
+func (c *LRU[K, V]) evictOldest() {
+	elem := c.order.Back()
+	if elem == nil {
+		return
+	}
+
+	entry := c.removeElement(elem)
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value)
+	}
+}
+
+// removeElement unlinks elem from both the list and the index.
+func (c *LRU[K, V]) removeElement(elem *list.Element) *lruEntry[K, V] {
+	entry := c.order.Remove(elem).(*lruEntry[K, V])
+	delete(c.items, entry.key)
+	c.cost -= entry.cost
+
+	return entry
+}
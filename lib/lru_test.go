@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLRU is the table-driven test for the cost-bounded LRU cache. Each case applies
+// a sequence of operations to a fresh cache with a capacity of ten and checks which keys
+// survive, which keys are reported as evicted, and the resulting total cost.
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	type op struct {
+		key  string
+		cost int64
+		get  bool
+	}
+
+	cases := []struct {
+		name        string
+		ops         []op
+		wantKeys    []string
+		wantEvicted []string
+		wantCost    int64
+	}{
+		{
+			name:     "Fits within capacity",
+			ops:      []op{{key: "a", cost: 3}, {key: "b", cost: 3}, {key: "c", cost: 4}},
+			wantKeys: []string{"a", "b", "c"},
+			wantCost: 10,
+		},
+		{
+			name:        "Evicts least recently added",
+			ops:         []op{{key: "a", cost: 4}, {key: "b", cost: 4}, {key: "c", cost: 4}},
+			wantKeys:    []string{"b", "c"},
+			wantEvicted: []string{"a"},
+			wantCost:    8,
+		},
+		{
+			name:        "Get refreshes recency",
+			ops:         []op{{key: "a", cost: 4}, {key: "b", cost: 4}, {key: "a", get: true}, {key: "c", cost: 4}},
+			wantKeys:    []string{"a", "c"},
+			wantEvicted: []string{"b"},
+			wantCost:    8,
+		},
+		{
+			name:        "Large entry evicts several",
+			ops:         []op{{key: "a", cost: 2}, {key: "b", cost: 2}, {key: "c", cost: 2}, {key: "d", cost: 8}},
+			wantKeys:    []string{"c", "d"},
+			wantEvicted: []string{"a", "b"},
+			wantCost:    10,
+		},
+		{
+			name:     "Replacing updates cost without eviction callback",
+			ops:      []op{{key: "a", cost: 8}, {key: "a", cost: 2}},
+			wantKeys: []string{"a"},
+			wantCost: 2,
+		},
+		{
+			name:     "Entry larger than capacity is rejected",
+			ops:      []op{{key: "a", cost: 2}, {key: "huge", cost: 11}},
+			wantKeys: []string{"a"},
+			wantCost: 2,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var evicted []string
+			cache := NewLRU[string, int](10, func(key string, _ int) { evicted = append(evicted, key) })
+
+			for _, o := range tt.ops {
+				if o.get {
+					_, ok := cache.Get(o.key)
+					assert.True(t, ok, "Key %q must be present", o.key)
+					continue
+				}
+
+				cache.Add(o.key, int(o.cost), o.cost)
+			}
+
+			for _, key := range tt.wantKeys {
+				value, ok := cache.Get(key)
+				assert.True(t, ok, "Key %q must survive", key)
+				assert.NotZero(t, value)
+			}
+
+			assert.Equal(t, tt.wantEvicted, evicted)
+			assert.Equal(t, len(tt.wantKeys), cache.Len())
+			assert.Equal(t, tt.wantCost, cache.Cost())
+		})
+	}
+}
+
+// TestLRURemove verifies that Remove deletes entries, releases their cost and does not
+// notify the eviction callback.
+func TestLRURemove(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[int, string](3, func(int, string) { t.Fatal("Remove must not trigger onEvict") })
+	cache.Add(1, "one", 1)
+	cache.Add(2, "two", 1)
+
+	assert.True(t, cache.Remove(1))
+	assert.False(t, cache.Remove(1), "Removing a missing key must report false")
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, int64(1), cache.Cost())
+
+	_, ok := cache.Get(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/goccy/go-json"
+)
+
+// CreateMergePatch returns a JSON Merge Patch (RFC 7396) that turns original into modified.
+// Unchanged members are omitted, removed members are set to null, changed objects are patched
+// recursively and every other changed value, including arrays, is replaced as a whole.
+// The patch is written in canonical form (see Canonicalize).
+//
+// Merge patches cannot express a member whose new value is null, because null means removal;
+// such members are removed instead.
+func CreateMergePatch(original, modified []byte) ([]byte, error) {
+	from, err := decodeAny(original)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := decodeAny(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendCanonical(nil, diffValues(from, to))
+}
+
+// diffValues returns the merge patch turning from into to.
+func diffValues(from, to any) any {
+	fromObj, ok := from.(map[string]any)
+	toObj, ok2 := to.(map[string]any)
+	if !ok || !ok2 {
+		return to
+	}
+
+	patch := make(map[string]any)
+	for key, toValue := range toObj {
+		fromValue, exists := fromObj[key]
+
+		switch {
+		case !exists:
+			patch[key] = toValue
+		case reflect.DeepEqual(fromValue, toValue):
+		default:
+			patch[key] = diffValues(fromValue, toValue)
+		}
+	}
+
+	for key := range fromObj {
+		if _, exists := toObj[key]; !exists {
+			patch[key] = nil
+		}
+	}
+
+	return patch
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to doc and returns the result in canonical form.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decodeAny(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decodeAny(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendCanonical(nil, applyPatch(target, p))
+}
+
+// applyPatch implements the MergePatch algorithm of RFC 7396, section 2.
+func applyPatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+
+		targetObj[key] = applyPatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// decodeAny decodes a JSON document into generic values, keeping numbers as json.Number.
+func decodeAny(src []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,65 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMergePatch is the table-driven test for MergePatch, using the examples of RFC 7396, appendix A.
+func TestMergePatch(t *testing.T) {
+	cases := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "Replace member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "Add member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "Remove member", doc: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "Nested objects", doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{name: "Arrays are replaced", doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "Non-object patch", doc: `{"a":"foo"}`, patch: `["c"]`, want: `["c"]`},
+		{name: "Object patch on non-object", doc: `["a"]`, patch: `{"a":"b"}`, want: `{"a":"b"}`},
+		{name: "Nulls inside new objects are dropped", doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+// TestCreateMergePatch verifies that created patches are minimal and that applying them
+// to the original document yields the modified document.
+func TestCreateMergePatch(t *testing.T) {
+	cases := []struct {
+		name      string
+		original  string
+		modified  string
+		wantPatch string
+	}{
+		{name: "Unchanged", original: `{"a":1,"b":[1,2]}`, modified: `{"b":[1,2],"a":1}`, wantPatch: `{}`},
+		{name: "Changed, added and removed members", original: `{"a":1,"b":2,"c":{"d":1,"e":2}}`, modified: `{"a":1,"c":{"d":1,"e":3},"f":true}`, wantPatch: `{"b":null,"c":{"e":3},"f":true}`},
+		{name: "Changed array", original: `{"a":[1,2,3]}`, modified: `{"a":[1,2]}`, wantPatch: `{"a":[1,2]}`},
+		{name: "Different top-level type", original: `{"a":1}`, modified: `[1]`, wantPatch: `[1]`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := CreateMergePatch([]byte(tt.original), []byte(tt.modified))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPatch, string(patch))
+
+			got, err := MergePatch([]byte(tt.original), patch)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.modified, string(got))
+		})
+	}
+
+	_, err := CreateMergePatch([]byte(`{`), []byte(`{}`))
+	assert.Error(t, err)
+}
@@ -1,6 +1,11 @@
 package lib
 
 import (
+	"errors"
+	"hash/crc32"
+	"io"
+	"sync"
+
 	"github.com/klauspost/compress/zstd"
 )
 
@@ -8,26 +13,115 @@ var (
 	// Shared global Z - standard encoder instance used by all ZSTDTranscoder objects.
 	// Initialized once at startup with maximum speed settings and high parallelism.
 	// Thread-safe and optimized for extremely high compression throughput.
-	encoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(10))
+	// EncodeAll output depends only on the input and these settings, never on concurrency,
+	// so equal inputs always compress to byte-identical frames. Every frame ends with a content
+	// checksum, so corrupted frames are detected on decompression.
+	encoder, _ = zstd.NewWriter(nil,
+		zstd.WithEncoderLevel(zstd.SpeedFastest),
+		zstd.WithEncoderConcurrency(10),
+		zstd.WithEncoderCRC(true),
+	)
 
 	// Shared global Z - standard decoder instance used by all ZSTDTranscoder objects.
 	// Pre-configured with multiple worker threads to achieve peak decompression performance.
 	// Thread-safe and designed for ultra-fast decompression in hot paths.
-	decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(4))
+	// Content checksums are verified whenever a frame carries one.
+	decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(4), zstd.IgnoreChecksum(false))
 )
 
+// ZSTDOptions configures a ZSTDTranscoder created by NewZSTDTranscoderWithOptions.
+// The zero value selects the settings of NewZSTDTranscoder.
+type ZSTDOptions struct {
+	// Level is the compression level. Levels outside the range supported by zstd select
+	// zstd.SpeedFastest.
+	Level zstd.EncoderLevel
+
+	// DisableChecksum omits the 4-byte content checksum from compressed frames. Frames without
+	// a checksum are smaller, but corruption is then only detected if it breaks the frame structure.
+	DisableChecksum bool
+}
+
 // ZSTDTranscoder provides zero-allocation, high-throughput Zstandard compression and decompression
 // by reusing globally pre-configured encoder and decoder instances.
-// It has no internal state - all heavy lifting is done by the shared, thread-safe global objects.
-// This design eliminates per-instance initialization overhead and maximizes performance
-// in hot paths (caching, messaging, logging, etc.) while remaining safe for concurrent use.
-type ZSTDTranscoder struct{}
+// It has no internal state besides its options - all heavy lifting is done by the shared,
+// thread-safe global objects. This design eliminates per-instance initialization overhead and
+// maximizes performance in hot paths (caching, messaging, logging, etc.) while remaining safe
+// for concurrent use.
+type ZSTDTranscoder struct {
+	opts ZSTDOptions
+}
 
 // NewZSTDTranscoder returns a lightweight transcoder instance that operates on the global
 // pre-initialized encoder and decoder. No allocation or setup is performed - the instance
 // is immediately ready for use and can be safely shared across the entire application.
 func NewZSTDTranscoder() *ZSTDTranscoder {
-	return &ZSTDTranscoder{}
+	return NewZSTDTranscoderWithOptions(ZSTDOptions{})
+}
+
+// NewZSTDTranscoderWithLevel returns a transcoder that compresses with the given level, trading
+// speed for smaller output. Every level uses a single shared encoder, created on first use.
+// Levels outside the range supported by zstd select zstd.SpeedFastest, the level of NewZSTDTranscoder.
+// Decompression does not depend on the level.
+func NewZSTDTranscoderWithLevel(level zstd.EncoderLevel) *ZSTDTranscoder {
+	return NewZSTDTranscoderWithOptions(ZSTDOptions{Level: level})
+}
+
+// NewZSTDTranscoderWithOptions returns a transcoder with the given options. Every combination of
+// options uses a single shared encoder, created on first use.
+func NewZSTDTranscoderWithOptions(opts ZSTDOptions) *ZSTDTranscoder {
+	if opts.Level < zstd.SpeedFastest || opts.Level > zstd.SpeedBestCompression {
+		opts.Level = zstd.SpeedFastest
+	}
+
+	return &ZSTDTranscoder{opts: opts}
+}
+
+// Level returns the compression level of the transcoder.
+func (t *ZSTDTranscoder) Level() zstd.EncoderLevel {
+	if t.opts.Level == 0 {
+		return zstd.SpeedFastest
+	}
+
+	return t.opts.Level
+}
+
+// Checksum reports whether compressed frames end with a content checksum.
+func (t *ZSTDTranscoder) Checksum() bool {
+	return !t.opts.DisableChecksum
+}
+
+// sharedEncoders holds the shared encoders for options other than the defaults.
+var (
+	sharedEncodersMu sync.Mutex
+	sharedEncoders   = make(map[ZSTDOptions]*zstd.Encoder)
+)
+
+// encoder returns the shared encoder for the options of the transcoder.
+func (t *ZSTDTranscoder) encoder() (*zstd.Encoder, error) {
+	opts := ZSTDOptions{Level: t.Level(), DisableChecksum: t.opts.DisableChecksum}
+	if opts == (ZSTDOptions{Level: zstd.SpeedFastest}) {
+		return encoder, nil
+	}
+
+	sharedEncodersMu.Lock()
+	defer sharedEncodersMu.Unlock()
+
+	if enc, ok := sharedEncoders[opts]; ok {
+		return enc, nil
+	}
+
+	enc, err := zstd.NewWriter(nil,
+		zstd.WithEncoderLevel(opts.Level),
+		zstd.WithEncoderConcurrency(10),
+		zstd.WithEncoderCRC(t.Checksum()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedEncoders[opts] = enc
+
+	return enc, nil
 }
 
 // Compress compresses the input data in a single fast operation using the shared global encoder.
@@ -35,10 +129,15 @@ func NewZSTDTranscoder() *ZSTDTranscoder {
 // a fully framed, independently decompression output.
 // The result is allocated once and returned - no internal buffers are reused.
 func (t *ZSTDTranscoder) Compress(src []byte) ([]byte, error) {
+	enc, err := t.encoder()
+	if err != nil {
+		return nil, err
+	}
+
 	// EncodeAll appends to the provided dest buffer; we pass a zero-length slice with capacity
 	// to avoid extra allocations while still getting a fresh result slice.
 	// Docs: https://github.com/klauspost/compress/tree/master/zstd#blocks
-	return encoder.EncodeAll(src, make([]byte, 0, len(src))), nil
+	return enc.EncodeAll(src, make([]byte, 0, len(src))), nil
 }
 
 // Decompress accepts Z - standard-compressed data and returns the original uncompressed bytes.
@@ -49,3 +148,203 @@ func (t *ZSTDTranscoder) Compress(src []byte) ([]byte, error) {
 func (t *ZSTDTranscoder) Decompress(src []byte) ([]byte, error) {
 	return decoder.DecodeAll(src, nil)
 }
+
+// ErrSizeLimit reports compressed data whose decompressed size exceeds the limit given to DecompressLimit.
+var ErrSizeLimit = errors.New("zstd: decompressed size exceeds limit")
+
+// minLimitedDecoderMemory is the smallest memory budget of the decoders used by DecompressLimit.
+// Frames written by streaming encoders declare windows of up to 8 MiB even for short content, so
+// smaller budgets would reject them; the exact limit is enforced on the decompressed result instead.
+const minLimitedDecoderMemory = 8 << 20
+
+// limitedDecoders holds the shared decoders of DecompressLimit, keyed by their memory budget.
+var (
+	limitedDecodersMu sync.Mutex
+	limitedDecoders   = make(map[uint64]*zstd.Decoder)
+)
+
+// DecompressLimit is Decompress for untrusted input: it fails with ErrSizeLimit as soon as the
+// content of src, which may consist of several concatenated frames, turns out to be larger than
+// limit bytes. Memory use stays bounded by the limit, or by 8 MiB for small limits, whether or not
+// the frames declare their content size, so small inputs cannot expand into huge allocations.
+func (t *ZSTDTranscoder) DecompressLimit(src []byte, limit int64) ([]byte, error) {
+	dec, err := limitedDecoder(uint64(max(limit, minLimitedDecoderMemory)))
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := dec.DecodeAll(src, nil)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return nil, errors.Join(ErrSizeLimit, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(out)) > limit {
+		return nil, ErrSizeLimit
+	}
+
+	return out, nil
+}
+
+// limitedDecoder returns the shared decoder with the given memory budget, creating it on first use.
+func limitedDecoder(maxMemory uint64) (*zstd.Decoder, error) {
+	limitedDecodersMu.Lock()
+	defer limitedDecodersMu.Unlock()
+
+	if dec, ok := limitedDecoders[maxMemory]; ok {
+		return dec, nil
+	}
+
+	dec, err := zstd.NewReader(nil,
+		zstd.WithDecoderConcurrency(4),
+		zstd.IgnoreChecksum(false),
+		zstd.WithDecoderMaxMemory(maxMemory),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	limitedDecoders[maxMemory] = dec
+
+	return dec, nil
+}
+
+// Header parses the header of the Z - standard frame at the start of src without decompressing it.
+// It reports metadata such as the declared content size, window size, dictionary ID and checksum flag,
+// which allows callers to reject oversized or unexpected frames before paying for decompression.
+func (t *ZSTDTranscoder) Header(src []byte) (zstd.Header, error) {
+	var header zstd.Header
+	err := header.Decode(src)
+	return header, err
+}
+
+// CompressWithDict compresses src using dict as a raw dictionary, i.e. as history that matches
+// can refer to. When src shares most of its content with dict, as consecutive versions of the same
+// document do, the frame only needs to describe the differences. The frame records an ID derived
+// from dict, so DecompressWithDict rejects frames presented with a different dictionary.
+// A dedicated encoder is created per call, which makes this slower than Compress for small inputs.
+func (t *ZSTDTranscoder) CompressWithDict(src, dict []byte) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil,
+		zstd.WithEncoderLevel(t.Level()),
+		zstd.WithEncoderConcurrency(1),
+		zstd.WithEncoderCRC(t.Checksum()),
+		zstd.WithEncoderDictRaw(dictID(dict), dict),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer enc.Close()
+
+	return enc.EncodeAll(src, make([]byte, 0, len(src)/4)), nil
+}
+
+// DecompressWithDict reverses CompressWithDict. The same dictionary must be supplied.
+func (t *ZSTDTranscoder) DecompressWithDict(src, dict []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(nil,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderDictRaw(dictID(dict), dict),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	return dec.DecodeAll(src, nil)
+}
+
+// dictID derives a non-zero dictionary ID from the dictionary content.
+// Zero is avoided because it means "no dictionary" in a frame header.
+func dictID(dict []byte) uint32 {
+	return crc32.ChecksumIEEE(dict) | 1
+}
+
+// NewWriter returns a streaming encoder that compresses everything written to it into a single
+// Z - standard frame on w, using the same level and checksum setting as Compress. Streams let consecutive writes
+// share one compression window, so redundancy between them is exploited. The caller must Close the
+// encoder to finish the frame; closing it does not close w.
+func (t *ZSTDTranscoder) NewWriter(w io.Writer) (*zstd.Encoder, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(t.Level()), zstd.WithEncoderCRC(t.Checksum()))
+}
+
+// NewReader returns a streaming decoder that decompresses the Z - standard frames read from r.
+// The caller must Close the decoder to release its resources.
+func (t *ZSTDTranscoder) NewReader(r io.Reader) (*zstd.Decoder, error) {
+	return zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+}
+
+// SplitFrames splits concatenated Z - standard frames into the individual frames without
+// decompressing them, by walking the frame and block headers. Skippable frames are included.
+// Frames can then be decompressed independently, for example in parallel.
+func (t *ZSTDTranscoder) SplitFrames(src []byte) ([][]byte, error) {
+	var frames [][]byte
+
+	for len(src) > 0 {
+		n, err := frameLen(src)
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, src[:n:n])
+		src = src[n:]
+	}
+
+	return frames, nil
+}
+
+// frameLen returns the total size of the frame at the start of src.
+func frameLen(src []byte) (int, error) {
+	var header zstd.Header
+	if err := header.Decode(src); err != nil {
+		return 0, err
+	}
+
+	if header.Skippable {
+		n := header.HeaderSize + int(header.SkippableSize)
+		if n > len(src) {
+			return 0, errors.New("zstd: truncated skippable frame")
+		}
+
+		return n, nil
+	}
+
+	// Every block starts with a 3-byte little-endian header: the last-block flag in bit 0,
+	// the block type in bits 1-2 and the block size in the remaining bits.
+	pos := header.HeaderSize
+	for {
+		if pos+3 > len(src) {
+			return 0, errors.New("zstd: truncated block header")
+		}
+
+		bh := uint32(src[pos]) | uint32(src[pos+1])<<8 | uint32(src[pos+2])<<16
+		pos += 3
+
+		size := int(bh >> 3)
+		switch (bh >> 1) & 3 {
+		case 1: // RLE blocks store a single byte repeated size times.
+			size = 1
+		case 3:
+			return 0, errors.New("zstd: reserved block type")
+		}
+
+		pos += size
+		if pos > len(src) {
+			return 0, errors.New("zstd: truncated block")
+		}
+
+		if bh&1 == 1 {
+			break
+		}
+	}
+
+	if header.HasCheckSum {
+		pos += 4
+		if pos > len(src) {
+			return 0, errors.New("zstd: truncated checksum")
+		}
+	}
+
+	return pos, nil
+}
@@ -1,9 +1,13 @@
 package lib
 
 import (
+	"bytes"
+	"fmt"
+	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -81,3 +85,289 @@ func TestZSTDTranscoder(t *testing.T) {
 		})
 	}
 }
+
+// TestZSTDTranscoderHeader verifies that Header reports the content size declared by frames
+// produced by Compress, and that it fails on input that does not start with a frame.
+// Frames of tiny inputs are allowed to omit the content size, as permitted by RFC 8878.
+func TestZSTDTranscoderHeader(t *testing.T) {
+	t.Parallel()
+
+	zstdTranscoder := NewZSTDTranscoder()
+
+	cases := []struct {
+		name    string
+		input   []byte
+		wantFCS bool
+		wantErr bool
+	}{
+		{name: "Short text", input: []byte("hello world")},
+		{name: "Long repeatable data", input: []byte(strings.Repeat("grok-xai-2025-", 100)), wantFCS: true},
+		{name: "Not a frame", input: []byte("definitely not zstd"), wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			src := tt.input
+			if !tt.wantErr {
+				compressed, err := zstdTranscoder.Compress(tt.input)
+				assert.NoError(t, err)
+				src = compressed
+			}
+
+			header, err := zstdTranscoder.Header(src)
+
+			if tt.wantErr {
+				assert.Error(t, err, "Header must fail for input without a frame")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFCS, header.HasFCS, "Unexpected content size flag")
+
+			if tt.wantFCS {
+				assert.Equal(t, uint64(len(tt.input)), header.FrameContentSize)
+			}
+		})
+	}
+}
+
+// TestZSTDTranscoderDict verifies that CompressWithDict exploits content shared with the dictionary,
+// that DecompressWithDict restores the input, and that a different dictionary is rejected.
+func TestZSTDTranscoderDict(t *testing.T) {
+	t.Parallel()
+
+	zstdTranscoder := NewZSTDTranscoder()
+
+	var sb strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&sb, `{"id":%d,"hash":"%x"},`, i, i*2654435761)
+	}
+
+	base := []byte(sb.String())
+	next := append(append([]byte(nil), base...), `{"id":500}`...)
+
+	plain, err := zstdTranscoder.Compress(next)
+	assert.NoError(t, err)
+
+	delta, err := zstdTranscoder.CompressWithDict(next, base)
+	assert.NoError(t, err)
+	assert.Less(t, len(delta)*10, len(plain), "Content shared with the dictionary must not be stored again")
+
+	got, err := zstdTranscoder.DecompressWithDict(delta, base)
+	assert.NoError(t, err)
+	assert.Equal(t, next, got)
+
+	_, err = zstdTranscoder.DecompressWithDict(delta, next)
+	assert.Error(t, err, "A different dictionary must be rejected")
+}
+
+// TestZSTDTranscoderSplitFrames verifies that concatenated frames, including raw, RLE
+// and skippable frames, are split at their exact boundaries and that truncated input is rejected.
+func TestZSTDTranscoderSplitFrames(t *testing.T) {
+	t.Parallel()
+
+	zstdTranscoder := NewZSTDTranscoder()
+
+	inputs := [][]byte{
+		[]byte(strings.Repeat("grok-xai-2025-", 1000)),
+		[]byte(strings.Repeat("a", 5000)),
+		[]byte("short"),
+	}
+
+	var concatenated []byte
+	var frames [][]byte
+
+	for _, input := range inputs {
+		frame, err := zstdTranscoder.Compress(input)
+		assert.NoError(t, err)
+
+		frames = append(frames, frame)
+		concatenated = append(concatenated, frame...)
+	}
+
+	skippable := []byte{0x50, 0x2a, 0x4d, 0x18, 3, 0, 0, 0, 'a', 'b', 'c'}
+	frames = append(frames, skippable)
+	concatenated = append(concatenated, skippable...)
+
+	got, err := zstdTranscoder.SplitFrames(concatenated)
+	assert.NoError(t, err)
+	assert.Equal(t, frames, got)
+
+	for i, input := range inputs {
+		decompressed, err := zstdTranscoder.Decompress(got[i])
+		assert.NoError(t, err)
+		assert.Equal(t, len(input), len(decompressed))
+	}
+
+	_, err = zstdTranscoder.SplitFrames(concatenated[:len(frames[0])-1])
+	assert.Error(t, err, "Truncated frames must be rejected")
+
+	_, err = zstdTranscoder.SplitFrames([]byte("not zstd"))
+	assert.Error(t, err)
+}
+
+// TestZSTDTranscoderLevel is the table-driven test for NewZSTDTranscoderWithLevel. It verifies
+// that every level round trips, that higher levels do not produce larger output for redundant
+// data, and that unsupported levels fall back to zstd.SpeedFastest.
+func TestZSTDTranscoderLevel(t *testing.T) {
+	t.Parallel()
+
+	input := []byte(strings.Repeat(`{"id":1,"name":"grok-xai-2025","tags":["a","b","c"]},`, 500))
+
+	fastest, err := NewZSTDTranscoder().Compress(input)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		level     zstd.EncoderLevel
+		wantLevel zstd.EncoderLevel
+	}{
+		{name: "Fastest", level: zstd.SpeedFastest, wantLevel: zstd.SpeedFastest},
+		{name: "Default", level: zstd.SpeedDefault, wantLevel: zstd.SpeedDefault},
+		{name: "Better", level: zstd.SpeedBetterCompression, wantLevel: zstd.SpeedBetterCompression},
+		{name: "Best", level: zstd.SpeedBestCompression, wantLevel: zstd.SpeedBestCompression},
+		{name: "Zero selects fastest", level: 0, wantLevel: zstd.SpeedFastest},
+		{name: "Out of range selects fastest", level: 42, wantLevel: zstd.SpeedFastest},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			zstdTranscoder := NewZSTDTranscoderWithLevel(tt.level)
+			assert.Equal(t, tt.wantLevel, zstdTranscoder.Level())
+
+			compressed, err := zstdTranscoder.Compress(input)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, len(compressed), len(fastest))
+
+			decompressed, err := NewZSTDTranscoder().Decompress(compressed)
+			assert.NoError(t, err)
+			assert.Equal(t, input, decompressed)
+		})
+	}
+}
+
+// TestZSTDTranscoderChecksum verifies that frames carry a content checksum unless it is disabled,
+// and that corrupted content is then reported as zstd.ErrCRCMismatch instead of decoding silently.
+func TestZSTDTranscoderChecksum(t *testing.T) {
+	t.Parallel()
+
+	input := []byte(`{"id":1,"name":"Alice"}`)
+
+	cases := []struct {
+		name         string
+		transcoder   *ZSTDTranscoder
+		wantChecksum bool
+	}{
+		{name: "Default", transcoder: NewZSTDTranscoder(), wantChecksum: true},
+		{name: "Other level", transcoder: NewZSTDTranscoderWithLevel(zstd.SpeedBestCompression), wantChecksum: true},
+		{name: "Disabled", transcoder: NewZSTDTranscoderWithOptions(ZSTDOptions{DisableChecksum: true})},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantChecksum, tt.transcoder.Checksum())
+
+			frame, err := tt.transcoder.Compress(input)
+			assert.NoError(t, err)
+
+			header, err := tt.transcoder.Header(frame)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChecksum, header.HasCheckSum)
+
+			// Short JSON is stored in a raw block, so flipping a content byte keeps the frame valid.
+			assert.False(t, header.FirstBlock.Compressed)
+
+			end := len(frame)
+			if tt.wantChecksum {
+				end -= 4
+			}
+
+			corrupted := append([]byte(nil), frame...)
+			corrupted[end-1] ^= 0x01
+
+			got, err := tt.transcoder.Decompress(corrupted)
+			if tt.wantChecksum {
+				assert.ErrorIs(t, err, zstd.ErrCRCMismatch)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEqual(t, input, got)
+		})
+	}
+}
+
+// TestZSTDTranscoderDecompressLimit is the table-driven test for DecompressLimit. It covers frames
+// with and without a declared content size, concatenated frames and corrupted input, and verifies
+// that oversized content is rejected without allocating memory for all of it.
+func TestZSTDTranscoderDecompressLimit(t *testing.T) {
+	t.Parallel()
+
+	zstdTranscoder := NewZSTDTranscoder()
+	const limit = 16 << 10
+
+	small, err := zstdTranscoder.Compress([]byte(strings.Repeat("a", limit/2)))
+	assert.NoError(t, err)
+
+	large, err := zstdTranscoder.Compress([]byte(strings.Repeat("a", limit+1)))
+	assert.NoError(t, err)
+
+	// A streamed frame does not declare its content size: 256 MiB of zeros in a few kilobytes.
+	var buf bytes.Buffer
+	w, err := zstdTranscoder.NewWriter(&buf)
+	assert.NoError(t, err)
+
+	zeros := make([]byte, 1<<20)
+	for i := 0; i < 256; i++ {
+		_, err = w.Write(zeros)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, w.Close())
+	streamed := buf.Bytes()
+
+	header, err := zstdTranscoder.Header(streamed)
+	assert.NoError(t, err)
+	assert.False(t, header.HasFCS)
+
+	cases := []struct {
+		name     string
+		input    []byte
+		wantLen  int
+		wantErr  error
+		anyError bool
+	}{
+		{name: "Within limit", input: small, wantLen: limit / 2},
+		{name: "Concatenated frames within limit", input: append(append([]byte(nil), small...), small...), wantLen: limit},
+		{name: "Declared size above limit", input: large, wantErr: ErrSizeLimit},
+		{name: "Undeclared size above limit", input: streamed, wantErr: ErrSizeLimit},
+		{name: "Concatenated frames above limit", input: append(append(append([]byte(nil), small...), small...), small...), wantErr: ErrSizeLimit},
+		{name: "Corrupted input", input: []byte("not zstd"), anyError: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := zstdTranscoder.DecompressLimit(tt.input, limit)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.anyError:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrSizeLimit)
+			default:
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+		})
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	_, err = zstdTranscoder.DecompressLimit(streamed, limit)
+	assert.ErrorIs(t, err, ErrSizeLimit)
+
+	runtime.ReadMemStats(&after)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<20), "Rejecting the stream must not decompress all of it")
+}
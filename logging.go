@@ -0,0 +1,163 @@
+package compressjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+)
+
+// logPrefixLen is the maximum number of input characters included in a log record.
+// Encoded payloads can be arbitrarily large, and only the beginning is needed
+// to recognize what kind of data was passed in.
+const logPrefixLen = 32
+
+// zstdMagic is the four-byte magic number that starts every Z - standard frame.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Input formats reported by detectFormat.
+const (
+	formatEmpty      = "empty"
+	formatJSON       = "json"
+	formatZSTD       = "zstd"
+	formatBase64ZSTD = "base64+zstd"
+	formatBase64     = "base64"
+	formatUnknown    = "unknown"
+)
+
+// detectFormat makes a best-effort guess about the format of an input string.
+// It only looks at the first few bytes, so it is cheap enough to call on every failure.
+// The result is meant for diagnostics only and never influences decoding.
+func detectFormat(src string) string {
+	if src == "" {
+		return formatEmpty
+	}
+
+	switch src[0] {
+	case '{', '[', '"':
+		return formatJSON
+	}
+
+	if len(src) >= len(zstdMagic) && src[:len(zstdMagic)] == string(zstdMagic) {
+		return formatZSTD
+	}
+
+	// Eight Base64 characters decode into six bytes, enough to check the frame magic.
+	head := src
+	if len(head) > 8 {
+		head = head[:8]
+	}
+
+	if len(head)%4 != 0 {
+		return formatUnknown
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(head)
+	if err != nil {
+		return formatUnknown
+	}
+
+	if bytes.HasPrefix(decoded, zstdMagic) {
+		return formatBase64ZSTD
+	}
+
+	return formatBase64
+}
+
+// truncate returns at most n leading bytes of src, marking the cut with an ellipsis.
+func truncate(src string, n int) string {
+	if len(src) <= n {
+		return src
+	}
+
+	return src[:n] + "..."
+}
+
+// logEncodeFailure reports a failed Encode stage. The value itself is never logged
+// because it may contain sensitive data; only its dynamic type is recorded.
+func (t *transcoder[T]) logEncodeFailure(stage string, src T, err error) {
+	if t.opts.logger == nil {
+		return
+	}
+
+	t.opts.logger.LogAttrs(context.Background(), slog.LevelDebug, "compressjson: encode failed",
+		slog.String("stage", stage),
+		slog.String("type", fmt.Sprintf("%T", src)),
+		slog.Any("error", err),
+	)
+}
+
+// logDecodeFailure reports a failed Decode stage together with the length and the detected
+// format of the offending input. Decoded JSON may contain sensitive data, so the input prefix
+// is only included for the encoded stages, see logPrefix.
+func (t *transcoder[T]) logDecodeFailure(stage, src string, err error) {
+	if t.opts.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("stage", stage),
+		slog.Int("input_len", len(src)),
+		slog.String("format", detectFormat(src)),
+	}
+
+	if prefix, ok := logPrefix(stage, src); ok {
+		attrs = append(attrs, slog.String("input_prefix", prefix))
+	}
+
+	attrs = append(attrs, slog.Any("error", err))
+
+	t.opts.logger.LogAttrs(context.Background(), slog.LevelDebug, "compressjson: decode failed", attrs...)
+}
+
+// logPrefix returns the prefix of a failed stage's input that may be logged. The Base64 input
+// is logged as text, compressed and enveloped binary input as hexadecimal, and the decompressed
+// JSON handled by the json and migrate stages is never logged.
+func logPrefix(stage, src string) (string, bool) {
+	switch stage {
+	case "base64":
+		return truncate(src, logPrefixLen), true
+	case "envelope", "zstd":
+		// Two hexadecimal digits per byte keep the prefix within logPrefixLen characters.
+		if len(src) > logPrefixLen/2 {
+			return hex.EncodeToString([]byte(src[:logPrefixLen/2])) + "...", true
+		}
+
+		return hex.EncodeToString([]byte(src)), true
+	default:
+		return "", false
+	}
+}
+
+// LogValue wraps an encoded string so that it can be passed to log/slog as an attribute value.
+// Decoding is deferred until a handler actually resolves the value, so records that are
+// filtered out by level never pay for Base64 decoding, decompression or unmarshalling.
+type LogValue[T any] struct {
+	transcoder Transcoder[T]
+	encoded    string
+}
+
+// NewLogValue returns a LogValue that decodes the encoded string with the given transcoder
+// when the log record is handled.
+func NewLogValue[T any](transcoder Transcoder[T], encoded string) LogValue[T] {
+	return LogValue[T]{transcoder: transcoder, encoded: encoded}
+}
+
+// LogValue implements slog.LogValuer. On success it returns the decoded value; if the
+// string cannot be decoded, it returns a group describing the input and the failure
+// instead, so logging never fails because of a corrupted payload.
+func (v LogValue[T]) LogValue() slog.Value {
+	decoded, err := v.transcoder.Decode(v.encoded)
+	if err != nil {
+		return slog.GroupValue(
+			slog.Int("input_len", len(v.encoded)),
+			slog.String("input_prefix", truncate(v.encoded, logPrefixLen)),
+			slog.String("format", detectFormat(v.encoded)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return slog.AnyValue(decoded)
+}
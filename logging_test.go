@@ -0,0 +1,135 @@
+package compressjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mocks "github.com/spacemagneto/compressjson/mocks"
+)
+
+// TestDetectFormat is the table-driven test for the detectFormat helper.
+// It verifies that plain JSON, raw Z - standard frames, Base64-wrapped frames,
+// arbitrary Base64 and garbage input are all recognized from their first bytes.
+func TestDetectFormat(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := NewTranscoder[user]().Encode(user{ID: 1})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Empty input", input: "", expected: formatEmpty},
+		{name: "JSON object", input: `{"id":1}`, expected: formatJSON},
+		{name: "JSON array", input: `[1,2,3]`, expected: formatJSON},
+		{name: "Raw zstd frame", input: string(zstdMagic) + "payload", expected: formatZSTD},
+		{name: "Encoded pipeline output", input: encoded, expected: formatBase64ZSTD},
+		{name: "Plain Base64", input: base64.StdEncoding.EncodeToString([]byte("hello world")), expected: formatBase64},
+		{name: "Garbage", input: "!!! not base64 !!!", expected: formatUnknown},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, detectFormat(tt.input), "Detected format mismatch for %q", tt.input)
+		})
+	}
+}
+
+// TestTranscoderWithLogger verifies that stage failures are reported through the configured
+// logger with the failing stage, the input length and the detected format, that input prefixes
+// are only logged for encoded input, and that successful calls do not produce any log output.
+func TestTranscoderWithLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	tr := NewTranscoder[user](WithLogger(logger))
+
+	encoded, err := tr.Encode(user{ID: 7, Name: "Dave"})
+	assert.NoError(t, err)
+
+	_, err = tr.Decode(encoded)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String(), "Successful calls must not log anything")
+
+	invalid := strings.Repeat("!", 100)
+	_, err = tr.Decode(invalid)
+	assert.Error(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "compressjson: decode failed")
+	assert.Contains(t, out, "stage=base64")
+	assert.Contains(t, out, "input_len=100")
+	assert.Contains(t, out, "format=unknown")
+	assert.NotContains(t, out, invalid, "The full input must never be logged")
+
+	buf.Reset()
+
+	frame, err := base64Stage.Decode(encoded)
+	assert.NoError(t, err)
+
+	truncated, err := base64Stage.Encode(frame[:len(frame)-1])
+	assert.NoError(t, err)
+
+	_, err = tr.Decode(truncated)
+	assert.ErrorIs(t, err, ErrDecompress)
+
+	out = buf.String()
+	assert.Contains(t, out, "stage=zstd")
+	assert.Contains(t, out, "input_prefix="+hex.EncodeToString(frame[:logPrefixLen/2])+"...", "Binary input must be logged as hexadecimal")
+
+	buf.Reset()
+
+	secret, err := NewTranscoder[map[string]string]().Encode(map[string]string{"id": "secret-token"})
+	assert.NoError(t, err)
+
+	_, err = tr.Decode(secret)
+	assert.ErrorIs(t, err, ErrUnmarshalJSON)
+
+	out = buf.String()
+	assert.Contains(t, out, "stage=json")
+	assert.NotContains(t, out, "input_prefix", "Decoded JSON must never be logged")
+	assert.NotContains(t, out, "secret-token", "Decoded JSON must never be logged")
+
+	buf.Reset()
+
+	_, err = NewTranscoder[struct{ F func() }](WithLogger(logger)).Encode(struct{ F func() }{F: func() {}})
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "stage=json")
+}
+
+// TestLogValue verifies that LogValue lazily decodes the wrapped string when a record is handled,
+// and that a corrupted payload is rendered as a diagnostic group instead of failing the log call.
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[user]()
+
+	encoded, err := tr.Encode(user{ID: 42, Name: "Alice"})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	logger.Info("cached", "user", NewLogValue(tr, encoded))
+	assert.Contains(t, buf.String(), `"user":{"id":42,"name":"Alice"}`)
+
+	buf.Reset()
+
+	logger.Info("cached", "user", NewLogValue(tr, "corrupted"))
+	assert.Contains(t, buf.String(), `"input_len":9`)
+	assert.Contains(t, buf.String(), `"error":"failed to decode Base64`)
+
+	buf.Reset()
+
+	logger.Debug("filtered", "user", NewLogValue(mocks.NewMockTranscoder[user](t), encoded))
+	assert.Empty(t, buf.String(), "Records below the handler level must not be decoded")
+}
@@ -0,0 +1,74 @@
+package compressjson
+
+import (
+	"hash/maphash"
+	"sync"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// Cloner is implemented by types that can produce a deep copy of themselves.
+// When a transcoder with a decode cache serves a value whose type implements Cloner,
+// every caller receives its own copy, so mutating the result never affects the cache.
+type Cloner[T any] interface {
+	Clone() T
+}
+
+// memoEntry is a decoded value together with the input it was decoded from.
+// The input is kept to rule out hash collisions.
+type memoEntry[T any] struct {
+	src   string
+	value T
+}
+
+// decodeMemo is a bounded, concurrency-safe LRU of decoded values keyed by
+// a fast hash of the encoded input.
+type decodeMemo[T any] struct {
+	mu      sync.Mutex
+	seed    maphash.Seed
+	entries *lib.LRU[uint64, memoEntry[T]]
+}
+
+// newDecodeMemo creates a memo holding at most size decoded values.
+func newDecodeMemo[T any](size int) *decodeMemo[T] {
+	return &decodeMemo[T]{
+		seed:    maphash.MakeSeed(),
+		entries: lib.NewLRU[uint64, memoEntry[T]](int64(size), nil),
+	}
+}
+
+// get returns the value previously decoded from src, cloned when T implements Cloner.
+func (m *decodeMemo[T]) get(src string) (T, bool) {
+	key := maphash.String(m.seed, src)
+
+	m.mu.Lock()
+	entry, ok := m.entries.Get(key)
+	m.mu.Unlock()
+
+	if !ok || entry.src != src {
+		var zero T
+		return zero, false
+	}
+
+	return clone(entry.value), true
+}
+
+// add remembers the value decoded from src. The stored value is a clone when T implements
+// Cloner, so the caller keeps exclusive ownership of the value it received from Decode.
+func (m *decodeMemo[T]) add(src string, value T) {
+	key := maphash.String(m.seed, src)
+	entry := memoEntry[T]{src: src, value: clone(value)}
+
+	m.mu.Lock()
+	m.entries.Add(key, entry, 1)
+	m.mu.Unlock()
+}
+
+// clone returns a deep copy of value if its type implements Cloner, and value itself otherwise.
+func clone[T any](value T) T {
+	if c, ok := any(value).(Cloner[T]); ok {
+		return c.Clone()
+	}
+
+	return value
+}
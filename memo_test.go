@@ -0,0 +1,130 @@
+package compressjson
+
+import (
+	"hash/maphash"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingUnmarshals counts UnmarshalJSON calls of countedConfig across the package tests.
+var countingUnmarshals atomic.Int64
+
+// countedConfig records every time it is unmarshaled, which reveals whether
+// Decode was served from the decode cache.
+type countedConfig struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+func (c *countedConfig) UnmarshalJSON(src []byte) error {
+	countingUnmarshals.Add(1)
+
+	type plain countedConfig
+	return json.Unmarshal(src, (*plain)(c))
+}
+
+// clonedConfig implements Cloner, so the decode cache hands out deep copies.
+type clonedConfig struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+func (c clonedConfig) Clone() clonedConfig {
+	flags := make(map[string]bool, len(c.Flags))
+	for key, value := range c.Flags {
+		flags[key] = value
+	}
+
+	return clonedConfig{Flags: flags}
+}
+
+// TestTranscoderDecodeCache verifies that WithDecodeCache serves repeated inputs without
+// unmarshalling again, that it is bounded in size, and that failed decodes are never cached.
+// It is not run in parallel because it observes the shared unmarshal counter.
+func TestTranscoderDecodeCache(t *testing.T) {
+	tr := NewTranscoder[countedConfig](WithDecodeCache(2))
+
+	encode := func(flag string) string {
+		encoded, err := tr.Encode(countedConfig{Flags: map[string]bool{flag: true}})
+		assert.NoError(t, err)
+		return encoded
+	}
+
+	a, b, c := encode("a"), encode("b"), encode("c")
+
+	cases := []struct {
+		name       string
+		input      string
+		wantDecode bool
+		wantFlag   string
+	}{
+		{name: "First decode of a", input: a, wantDecode: true, wantFlag: "a"},
+		{name: "Cached a", input: a, wantFlag: "a"},
+		{name: "First decode of b", input: b, wantDecode: true, wantFlag: "b"},
+		{name: "Cached b", input: b, wantFlag: "b"},
+		{name: "First decode of c evicts a", input: c, wantDecode: true, wantFlag: "c"},
+		{name: "Evicted a is decoded again", input: a, wantDecode: true, wantFlag: "a"},
+		{name: "Cached c", input: c, wantFlag: "c"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			before := countingUnmarshals.Load()
+
+			got, err := tr.Decode(tt.input)
+			assert.NoError(t, err)
+			assert.True(t, got.Flags[tt.wantFlag])
+
+			decoded := countingUnmarshals.Load() != before
+			assert.Equal(t, tt.wantDecode, decoded, "Unexpected cache behavior")
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := tr.Decode("corrupted")
+		assert.Error(t, err, "Failed decodes must never be served from the cache")
+	}
+}
+
+// TestTranscoderDecodeCacheClone verifies that values implementing Cloner are copied both
+// when they enter the cache and when they leave it, so callers can mutate results freely.
+func TestTranscoderDecodeCacheClone(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[clonedConfig](WithDecodeCache(8))
+
+	encoded, err := tr.Encode(clonedConfig{Flags: map[string]bool{"beta": true}})
+	assert.NoError(t, err)
+
+	first, err := tr.Decode(encoded)
+	assert.NoError(t, err)
+	first.Flags["beta"] = false
+
+	second, err := tr.Decode(encoded)
+	assert.NoError(t, err)
+	assert.True(t, second.Flags["beta"], "Mutating a decoded value must not affect the cache")
+	second.Flags["beta"] = false
+
+	third, err := tr.Decode(encoded)
+	assert.NoError(t, err)
+	assert.True(t, third.Flags["beta"], "Mutating a cached value must not affect later decodes")
+}
+
+// TestDecodeMemoCollision verifies that an entry stored under the same hash but for
+// a different input is never returned.
+func TestDecodeMemoCollision(t *testing.T) {
+	t.Parallel()
+
+	memo := newDecodeMemo[int](4)
+	memo.entries.Add(maphash.String(memo.seed, "other"), memoEntry[int]{src: "input", value: 42}, 1)
+
+	_, ok := memo.get("other")
+	assert.False(t, ok, "A hash match with a different input must be a miss")
+
+	memo.add("input", 7)
+
+	value, ok := memo.get("input")
+	assert.True(t, ok)
+	assert.Equal(t, 7, value)
+}
@@ -0,0 +1,201 @@
+package compressjson
+
+import (
+	"hash"
+	"log/slog"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// Option configures optional behavior of a transcoder created by NewTranscoder.
+// Options are applied once at construction time; the resulting transcoder is
+// immutable afterwards and remains safe for concurrent use.
+type Option func(*options)
+
+// options holds the optional settings shared by all transcoder implementations
+// in this package. Apart from the checksum mode, which defaults to ChecksumFrame,
+// the zero value describes the default pipeline behavior.
+type options struct {
+	logger            *slog.Logger
+	maxBodySize       int64
+	decodeCacheSize   int
+	schemaVersion     uint32
+	migrations        map[uint32]MigrationFunc
+	json              lib.JSONOptions
+	digestHash        func() hash.Hash
+	base64Records     bool
+	archiveBlockSize  int
+	parallelBlockSize int
+	compressionLevel  zstd.EncoderLevel
+	checksum          ChecksumMode
+}
+
+// newOptions applies the given functional options on top of the defaults.
+func newOptions(opts []Option) options {
+	o := options{checksum: ChecksumFrame}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	return o
+}
+
+// WithLogger attaches a structured logger to the transcoder. Every stage failure
+// during Encode or Decode is reported at debug level together with the input length,
+// a truncated prefix of the offending input and its detected format.
+// A nil logger disables logging, which is also the default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMaxBodySize limits the size of HTTP bodies read by DecodeRequest, Middleware and
+// RoundTripper, both as received on the wire and after decompression. Larger bodies are rejected with ErrBodyTooLarge.
+// Values less than or equal to zero select DefaultMaxBodySize.
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// WithDecodeCache memoizes up to size successfully decoded values, keyed by a fast hash of the
+// encoded input, so that repeatedly decoding the same string skips Base64 decoding, decompression
+// and unmarshalling. Least recently used values are dropped first.
+//
+// Cached values are shared between callers. If T implements Cloner, every Decode returns a fresh
+// deep copy; otherwise callers must treat decoded values as immutable, because mutating one would
+// change what later Decode calls return. Values less than or equal to zero disable the cache,
+// which is also the default.
+func WithDecodeCache(size int) Option {
+	return func(o *options) {
+		o.decodeCacheSize = size
+	}
+}
+
+// WithSchemaVersion records the schema version of T in every encoded value. Values encoded with
+// an older version are upgraded on Decode by the migrations registered with WithMigration before
+// they are unmarshaled. Version zero, the default, writes bare frames without version information;
+// every decoder treats such data as version zero.
+func WithSchemaVersion(version uint32) Option {
+	return func(o *options) {
+		o.schemaVersion = version
+	}
+}
+
+// WithMigration registers the migration that upgrades the JSON form of a value from version
+// fromVersion to fromVersion+1. Decode applies the chain of migrations from the version the
+// data was written with up to the version configured with WithSchemaVersion.
+// Registering a second migration for the same version replaces the first one.
+func WithMigration(fromVersion uint32, fn MigrationFunc) Option {
+	return func(o *options) {
+		if o.migrations == nil {
+			o.migrations = make(map[uint32]MigrationFunc)
+		}
+
+		o.migrations[fromVersion] = fn
+	}
+}
+
+// WithDisallowUnknownFields makes Decode reject JSON objects with keys that do not match
+// any field of the target type, instead of silently dropping them.
+func WithDisallowUnknownFields() Option {
+	return func(o *options) {
+		o.json.DisallowUnknownFields = true
+	}
+}
+
+// WithUseNumber makes Decode store numbers inside interface values, such as map[string]any,
+// as json.Number instead of float64, so large integers keep their exact value.
+func WithUseNumber() Option {
+	return func(o *options) {
+		o.json.UseNumber = true
+	}
+}
+
+// WithMaxDepth limits how deeply objects and arrays may be nested in decoded JSON.
+// Deeper input is rejected with an error wrapping lib.ErrMaxDepth before it is unmarshaled.
+// Values less than or equal to zero disable the limit, which is also the default.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.json.MaxDepth = n
+	}
+}
+
+// WithMaxStringLength limits the length in bytes of every string and object key in decoded JSON.
+// Longer strings are rejected with an error wrapping lib.ErrMaxStringLength before the input is
+// unmarshaled. Values less than or equal to zero disable the limit, which is also the default.
+func WithMaxStringLength(n int) Option {
+	return func(o *options) {
+		o.json.MaxStringLength = n
+	}
+}
+
+// WithCanonicalJSON makes Encode marshal values to canonical JSON (RFC 8785): object members
+// sorted by key, numbers in their shortest normalized form and strings without HTML escaping.
+// Combined with the fixed compression settings of each level, equal values then always produce
+// byte-identical encoded strings, which can be compared or hashed for deduplication.
+// Canonicalization costs an additional pass over the marshaled JSON.
+func WithCanonicalJSON() Option {
+	return func(o *options) {
+		o.json.Canonical = true
+	}
+}
+
+// WithDigestHash selects the hash function used by EncodeWithDigest and Digest, for example
+// a non-cryptographic hash such as xxhash when digests only serve as deduplication keys.
+// A nil function selects SHA-256, which is also the default.
+func WithDigestHash(newHash func() hash.Hash) Option {
+	return func(o *options) {
+		o.digestHash = newHash
+	}
+}
+
+// WithBase64Records makes RecordWriter encode the compressed record stream to standard Base64,
+// for carriers that only accept text. RecordReader must be created with the same option.
+func WithBase64Records() Option {
+	return func(o *options) {
+		o.base64Records = true
+	}
+}
+
+// WithArchiveBlockSize sets the number of records ArchiveWriter compresses into each independent block.
+// Values less than or equal to zero select DefaultArchiveBlockSize.
+func WithArchiveBlockSize(n int) Option {
+	return func(o *options) {
+		o.archiveBlockSize = n
+	}
+}
+
+// WithParallelBlockSize sets the number of slice elements the transcoder returned by
+// NewParallelTranscoder marshals and compresses per goroutine. Slices up to this length are
+// encoded as a single frame. Values less than or equal to zero select DefaultParallelBlockSize.
+func WithParallelBlockSize(n int) Option {
+	return func(o *options) {
+		o.parallelBlockSize = n
+	}
+}
+
+// WithCompressionLevel sets the Z - standard level used by Encode and by every integration built on
+// the transcoder, trading encoding speed for smaller output. Decoding does not depend on the level,
+// so values written with any level decode with any transcoder. The default, zstd.SpeedFastest,
+// is also selected by levels outside the range supported by zstd.
+func WithCompressionLevel(level zstd.EncoderLevel) Option {
+	return func(o *options) {
+		o.compressionLevel = level
+	}
+}
+
+// WithChecksum selects the integrity checks written by Encode and by every integration built on the
+// transcoder. Decode verifies every checksum present in its input, whatever the mode of the encoding
+// transcoder was, and reports a mismatch with an error wrapping ErrChecksumMismatch instead of
+// a confusing JSON error or a silently wrong value. The default is ChecksumFrame.
+func WithChecksum(mode ChecksumMode) Option {
+	return func(o *options) {
+		o.checksum = mode
+	}
+}
@@ -0,0 +1,190 @@
+package compressjson
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// DefaultParallelBlockSize is the number of slice elements per block used by the transcoder
+// returned from NewParallelTranscoder when WithParallelBlockSize is not set.
+const DefaultParallelBlockSize = 4096
+
+// parallelTranscoder implements Transcoder[[]E] by splitting large slices into blocks that are
+// marshaled and compressed concurrently into independent, concatenated Z - standard frames.
+// Every frame holds a fragment of one JSON array: the first starts with '[', the others with ','
+// and the last ends with ']'. Decompressing all frames in order therefore yields exactly the JSON
+// array of the whole slice, which any standard decoder, including Transcoder.Decode, can read.
+type parallelTranscoder[E any] struct {
+	t     *transcoder[[]E]
+	block int
+}
+
+// NewParallelTranscoder creates a transcoder for slices that uses all available cores for large inputs.
+// Slices longer than the block size (see WithParallelBlockSize) are encoded as one frame per block,
+// and such encodings are decoded one frame per goroutine while preserving element order. Shorter
+// slices, and encodings produced by other transcoders, are processed like by NewTranscoder.
+// The output of both transcoders is interchangeable.
+func NewParallelTranscoder[E any](opts ...Option) Transcoder[[]E] {
+	t := newTranscoder[[]E](opts...)
+
+	block := t.opts.parallelBlockSize
+	if block <= 0 {
+		block = DefaultParallelBlockSize
+	}
+
+	return &parallelTranscoder[E]{t: t, block: block}
+}
+
+// Encode marshals and compresses the blocks of src concurrently and encodes the concatenated
+// frames to standard Base64.
+func (p *parallelTranscoder[E]) Encode(src []E) (string, error) {
+	if len(src) <= p.block {
+		return p.t.Encode(src)
+	}
+
+	n := (len(src) + p.block - 1) / p.block
+	frames := make([][]byte, n)
+
+	err := runParallel(n, func(i int) error {
+		part := src[i*p.block : min((i+1)*p.block, len(src))]
+
+		jsonBytes, err := p.t.jsonTranscoder.Marshal(part)
+		if err != nil {
+			p.t.logEncodeFailure("json", part, err)
+			return errors.Join(ErrMarshalJSON, err)
+		}
+
+		// Turn the array of this block into its fragment of the complete array.
+		if i > 0 {
+			jsonBytes[0] = ','
+		}
+
+		if i < n-1 {
+			jsonBytes = jsonBytes[:len(jsonBytes)-1]
+		}
+
+		frames[i], err = p.t.standardTranscoder.Compress(jsonBytes)
+		if err != nil {
+			p.t.logEncodeFailure("zstd", part, err)
+			return errors.Join(ErrCompress, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var size int
+	for _, frame := range frames {
+		size += len(frame)
+	}
+
+	concatenated := make([]byte, 0, size)
+	for _, frame := range frames {
+		concatenated = append(concatenated, frame...)
+	}
+
+	out, err := versionedFrame(concatenated, p.t.opts.schemaVersion, p.t.opts.checksum&ChecksumCRC32C != 0)
+	if err != nil {
+		return "", err
+	}
+
+	return p.t.binaryTranscoder.Encode(out)
+}
+
+// Decode decompresses and unmarshals the frames of src concurrently and joins the blocks in order.
+// Input that does not consist of array fragments, such as a single frame or frames split at other
+// boundaries, and input that needs a schema migration is decoded sequentially instead.
+func (p *parallelTranscoder[E]) Decode(src string) ([]E, error) {
+	compressedBytes, err := p.t.binaryTranscoder.Decode(src)
+	if err != nil {
+		p.t.logDecodeFailure("base64", src, err)
+		return nil, errors.Join(ErrDecodeBase64, err)
+	}
+
+	payload, version, err := splitVersionedFrame(compressedBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	frames, err := p.t.standardTranscoder.SplitFrames(payload)
+	if err != nil || len(frames) < 2 || version != p.t.opts.schemaVersion {
+		return p.t.decodeFrame(payload, version)
+	}
+
+	parts := make([][]E, len(frames))
+
+	err = runParallel(len(frames), func(i int) error {
+		fragment, err := p.t.standardTranscoder.Decompress(frames[i])
+		if err != nil {
+			return err
+		}
+
+		want := byte(',')
+		if i == 0 {
+			want = '['
+		}
+
+		if len(fragment) == 0 || fragment[0] != want {
+			return fmt.Errorf("frame %d is not an array fragment", i)
+		}
+
+		// Turn the fragment back into a complete array of this block.
+		fragment[0] = '['
+		if i < len(frames)-1 {
+			fragment = append(fragment, ']')
+		}
+
+		parts[i], err = p.t.jsonTranscoder.Unmarshal(fragment)
+		if err == nil && len(parts[i]) == 0 {
+			// Empty fragments are never written and would hide a stray comma, as in "[1,]".
+			return fmt.Errorf("frame %d holds no elements", i)
+		}
+
+		return err
+	})
+	if err != nil {
+		return p.t.decodeFrame(payload, version)
+	}
+
+	var total int
+	for _, part := range parts {
+		total += len(part)
+	}
+
+	entry := make([]E, 0, total)
+	for _, part := range parts {
+		entry = append(entry, part...)
+	}
+
+	return entry, nil
+}
+
+// runParallel calls fn for every index below n on up to GOMAXPROCS goroutines
+// and returns the errors of all failed calls joined together.
+func runParallel(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			errs[i] = fn(i)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
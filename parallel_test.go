@@ -0,0 +1,132 @@
+package compressjson
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParallelTranscoder is the table-driven test for NewParallelTranscoder. It verifies the round trip
+// for slices around the block size, the number of frames produced, and that the output interoperates
+// with the sequential transcoder in both directions.
+func TestParallelTranscoder(t *testing.T) {
+	t.Parallel()
+
+	users := make([]user, 1000)
+	for i := range users {
+		users[i] = user{ID: i, Name: fmt.Sprintf("user-%d", i), Age: i % 90}
+	}
+
+	cases := []struct {
+		name       string
+		input      []user
+		opts       []Option
+		wantFrames int
+	}{
+		{name: "Nil slice", input: nil, wantFrames: 1},
+		{name: "Empty slice", input: []user{}, wantFrames: 1},
+		{name: "Below block size", input: users[:99], opts: []Option{WithParallelBlockSize(100)}, wantFrames: 1},
+		{name: "Exactly two blocks", input: users[:200], opts: []Option{WithParallelBlockSize(100)}, wantFrames: 2},
+		{name: "Partial last block", input: users, opts: []Option{WithParallelBlockSize(64)}, wantFrames: 16},
+		{name: "Single element blocks", input: users[:10], opts: []Option{WithParallelBlockSize(1)}, wantFrames: 10},
+		{name: "Versioned output", input: users, opts: []Option{WithParallelBlockSize(300), WithSchemaVersion(2)}, wantFrames: 4},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			parallel := NewParallelTranscoder[user](tt.opts...)
+			sequential := NewTranscoder[[]user](tt.opts...)
+
+			encoded, err := parallel.Encode(tt.input)
+			assert.NoError(t, err)
+
+			raw, err := base64Stage.Decode(encoded)
+			assert.NoError(t, err)
+
+			payload, _, err := splitVersionedFrame(raw)
+			assert.NoError(t, err)
+
+			frames, err := zstdStage.SplitFrames(payload)
+			assert.NoError(t, err)
+			assert.Len(t, frames, tt.wantFrames)
+
+			got, err := parallel.Decode(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, got)
+
+			got, err = sequential.Decode(encoded)
+			assert.NoError(t, err, "Concatenated frames must form a single JSON array")
+			assert.Equal(t, tt.input, got)
+
+			encoded, err = sequential.Encode(tt.input)
+			assert.NoError(t, err)
+
+			got, err = parallel.Decode(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, got)
+		})
+	}
+}
+
+// TestParallelTranscoderFallback verifies that frames split at arbitrary positions of the JSON
+// array are decoded sequentially, and that invalid input still reports the stage that failed.
+func TestParallelTranscoderFallback(t *testing.T) {
+	t.Parallel()
+
+	tr := NewParallelTranscoder[int]()
+
+	first, err := zstdStage.Compress([]byte(`[1,2`))
+	assert.NoError(t, err)
+
+	second, err := zstdStage.Compress([]byte(`3,4]`))
+	assert.NoError(t, err)
+
+	encoded, err := base64Stage.Encode(append(first, second...))
+	assert.NoError(t, err)
+
+	got, err := tr.Decode(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 23, 4}, got)
+
+	broken, err := zstdStage.Compress([]byte(`,]`))
+	assert.NoError(t, err)
+
+	encoded, err = base64Stage.Encode(append(first, broken...))
+	assert.NoError(t, err)
+
+	_, err = tr.Decode(encoded)
+	assert.ErrorIs(t, err, ErrUnmarshalJSON)
+
+	_, err = tr.Decode("!!!")
+	assert.ErrorIs(t, err, ErrDecodeBase64)
+}
+
+// BenchmarkParallelTranscoder compares the parallel and sequential transcoders on a large slice.
+func BenchmarkParallelTranscoder(b *testing.B) {
+	users := make([]user, 100_000)
+	for i := range users {
+		users[i] = user{ID: i, Name: fmt.Sprintf("user-%d", i), Email: fmt.Sprintf("user-%d@example.com", i)}
+	}
+
+	for _, bc := range []struct {
+		name string
+		tr   Transcoder[[]user]
+	}{
+		{name: "Sequential", tr: NewTranscoder[[]user]()},
+		{name: "Parallel", tr: NewParallelTranscoder[user]()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				encoded, err := bc.tr.Encode(users)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err = bc.tr.Decode(encoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
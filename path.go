@@ -0,0 +1,70 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrInvalidPath reports a path expression that cannot be parsed.
+	ErrInvalidPath = errors.New("invalid path")
+
+	// ErrPathNotFound reports that a path matches nothing in the decoded document.
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// DecodePath extracts the JSON value at path from a string produced by Encode without unmarshalling
+// the whole document into its type. The string is decoded and decompressed, and the JSON is then
+// scanned for the requested value only.
+//
+// Paths use the JSONPath subset supported by github.com/goccy/go-json, such as "$.status",
+// "$.items[0].id" or "$.items[*].id". Paths with a wildcard return all matches as a JSON array, even
+// when there is only one; other paths return the single matching value. When nothing matches, the
+// error wraps ErrPathNotFound. Schema migrations are not
+// applied, so the path refers to the document as it was stored.
+func DecodePath(encoded, path string) (json.RawMessage, error) {
+	p, err := json.CreatePath(path)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidPath, err)
+	}
+
+	jsonBytes, err := DecodeRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := p.Extract(jsonBytes)
+	if err != nil {
+		return nil, errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+
+	if len(matches) == 1 && !strings.Contains(path, "*") {
+		return matches[0], nil
+	}
+
+	return append(append([]byte{'['}, bytes.Join(matches, []byte{','})...), ']'), nil
+}
+
+// DecodeField extracts the value at path like DecodePath and unmarshals it into a new value of type F.
+func DecodeField[F any](encoded, path string) (F, error) {
+	var field F
+
+	raw, err := DecodePath(encoded, path)
+	if err != nil {
+		return field, err
+	}
+
+	if err = json.Unmarshal(raw, &field); err != nil {
+		return field, errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	return field, nil
+}
@@ -0,0 +1,88 @@
+package compressjson
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+type purchase struct {
+	ID     int        `json:"id"`
+	Status string     `json:"status"`
+	Items  []lineItem `json:"items"`
+}
+
+type lineItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// TestDecodePath is the table-driven test for DecodePath. It covers single and multiple matches,
+// missing paths, invalid expressions and versioned input.
+func TestDecodePath(t *testing.T) {
+	t.Parallel()
+
+	value := purchase{ID: 7, Status: "shipped", Items: []lineItem{{SKU: "a-1", Quantity: 2}, {SKU: "b-2", Quantity: 1}}}
+
+	encoded, err := NewTranscoder[purchase]().Encode(value)
+	assert.NoError(t, err)
+
+	versioned, err := NewTranscoder[purchase](WithSchemaVersion(3)).Encode(value)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		input   string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "Top-level field", input: encoded, path: "$.status", want: `"shipped"`},
+		{name: "Nested field", input: encoded, path: "$.items[1].sku", want: `"b-2"`},
+		{name: "Object", input: encoded, path: "$.items[0]", want: `{"sku":"a-1","quantity":2}`},
+		{name: "Several matches", input: encoded, path: "$.items[*].quantity", want: `[2,1]`},
+		{name: "Versioned input", input: versioned, path: "$.id", want: `7`},
+		{name: "Missing field", input: encoded, path: "$.customer", wantErr: ErrPathNotFound},
+		{name: "Index out of range", input: encoded, path: "$.items[5]", wantErr: ErrPathNotFound},
+		{name: "Invalid path", input: encoded, path: "status", wantErr: ErrInvalidPath},
+		{name: "Invalid input", input: "!!!", path: "$.id", wantErr: ErrDecodeBase64},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePath(tt.input, tt.path)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+// TestDecodeField verifies that DecodeField unmarshals the extracted value into the requested type.
+func TestDecodeField(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := NewTranscoder[purchase]().Encode(purchase{ID: 7, Status: "shipped", Items: []lineItem{{SKU: "a-1", Quantity: 2}}})
+	assert.NoError(t, err)
+
+	status, err := DecodeField[string](encoded, "$.status")
+	assert.NoError(t, err)
+	assert.Equal(t, "shipped", status)
+
+	item, err := DecodeField[lineItem](encoded, "$.items[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, lineItem{SKU: "a-1", Quantity: 2}, item)
+
+	quantities, err := DecodeField[[]json.Number](encoded, "$.items[*].quantity")
+	assert.NoError(t, err)
+	assert.Equal(t, []json.Number{"2"}, quantities)
+
+	_, err = DecodeField[int](encoded, "$.status")
+	assert.ErrorIs(t, err, ErrUnmarshalJSON)
+}
@@ -0,0 +1,210 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/goccy/go-json"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// ErrUnknownType reports a value whose concrete type, or an encoded payload whose type
+// discriminator, is not registered in the Registry used by a polymorphic transcoder.
+var ErrUnknownType = errors.New("unknown type")
+
+// registryEntry describes a concrete type registered under a discriminator name.
+type registryEntry[I any] struct {
+	name   string
+	decode func([]byte, lib.JSONOptions) (I, error)
+}
+
+// Registry maps discriminator names to concrete types implementing the interface I.
+// It is used by NewPolymorphicTranscoder to encode and decode values stored behind I.
+// Types are usually registered once during initialization; a Registry is nevertheless
+// safe for concurrent use, so registration may also happen while transcoders are in use.
+type Registry[I any] struct {
+	mu     sync.RWMutex
+	byName map[string]registryEntry[I]
+	byType map[reflect.Type]registryEntry[I]
+}
+
+// NewRegistry creates an empty registry for implementations of I.
+func NewRegistry[I any]() *Registry[I] {
+	return &Registry[I]{
+		byName: make(map[string]registryEntry[I]),
+		byType: make(map[reflect.Type]registryEntry[I]),
+	}
+}
+
+// Register adds the concrete type C to reg under the given discriminator name. C must implement I;
+// register the pointer type when the methods of I have pointer receivers. Names and types must be
+// unique within a registry, and names must stay stable because they are stored in encoded values.
+func Register[I, C any](reg *Registry[I], name string) error {
+	typ := reflect.TypeFor[C]()
+	if !typ.Implements(reflect.TypeFor[I]()) {
+		return fmt.Errorf("type %s does not implement %s", typ, reflect.TypeFor[I]())
+	}
+
+	if name == "" {
+		return fmt.Errorf("empty name for type %s", typ)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, ok := reg.byName[name]; ok {
+		return fmt.Errorf("name %q is already registered", name)
+	}
+
+	if _, ok := reg.byType[typ]; ok {
+		return fmt.Errorf("type %s is already registered", typ)
+	}
+
+	entry := registryEntry[I]{
+		name: name,
+		decode: func(src []byte, opts lib.JSONOptions) (I, error) {
+			value, err := lib.NewJSONTranscoderWithOptions[C](opts).Unmarshal(src)
+			if err != nil {
+				var zero I
+				return zero, err
+			}
+
+			return any(value).(I), nil
+		},
+	}
+
+	reg.byName[name] = entry
+	reg.byType[typ] = entry
+
+	return nil
+}
+
+// lookupType returns the entry registered for the dynamic type of v.
+func (r *Registry[I]) lookupType(v I) (registryEntry[I], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.byType[reflect.TypeOf(v)]
+
+	return entry, ok
+}
+
+// lookupName returns the entry registered under name.
+func (r *Registry[I]) lookupName(name string) (registryEntry[I], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.byName[name]
+
+	return entry, ok
+}
+
+// polymorphicTranscoder implements Transcoder[I] for an interface type I. Every encoded value
+// is a binary envelope whose Type field holds the registered name of the concrete type.
+type polymorphicTranscoder[I any] struct {
+	registry *Registry[I]
+	raw      *transcoder[json.RawMessage]
+}
+
+// NewPolymorphicTranscoder creates a transcoder for values of the interface type I, such as events
+// on a shared stream. The concrete type of every value must be registered in reg. Encoded values
+// record the registered name as a type discriminator, so Decode returns the same concrete type
+// behind I. Options apply to all registered types; migrations registered with WithMigration
+// therefore need to handle the JSON of every type.
+func NewPolymorphicTranscoder[I any](reg *Registry[I], opts ...Option) Transcoder[I] {
+	return &polymorphicTranscoder[I]{registry: reg, raw: newTranscoder[json.RawMessage](opts...)}
+}
+
+// Encode marshals the concrete value behind src, compresses it, wraps it into an envelope
+// tagged with the registered type name and encodes the result to standard Base64.
+func (t *polymorphicTranscoder[I]) Encode(src I) (string, error) {
+	entry, ok := t.registry.lookupType(src)
+	if !ok {
+		return "", fmt.Errorf("%w: %T is not registered", ErrUnknownType, src)
+	}
+
+	jsonBytes, err := json.Marshal(src)
+	if err != nil {
+		return "", errors.Join(ErrMarshalJSON, err)
+	}
+
+	frame, err := t.raw.encodeFrame(jsonBytes)
+	if err != nil {
+		return "", err
+	}
+
+	env := Envelope{
+		Type:            entry.name,
+		SchemaVersion:   t.raw.schemaVersion(),
+		ContentEncoding: ContentEncodingZSTD,
+		Payload:         frame,
+	}
+
+	if t.raw.opts.checksum&ChecksumCRC32C != 0 {
+		env.Checksum = payloadChecksum(frame)
+	}
+
+	envBytes, err := env.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+
+	return t.raw.binaryTranscoder.Encode(envBytes)
+}
+
+// Decode reverses Encode and returns the registered concrete type behind I. The error wraps
+// ErrUnknownType when the envelope carries no type discriminator or one that is not registered.
+func (t *polymorphicTranscoder[I]) Decode(src string) (I, error) {
+	var entry I
+
+	envBytes, err := t.raw.binaryTranscoder.Decode(src)
+	if err != nil {
+		t.raw.logDecodeFailure("base64", src, err)
+		return entry, errors.Join(ErrDecodeBase64, err)
+	}
+
+	// Bare frames are produced by transcoders without a registry and carry no discriminator.
+	if bytes.HasPrefix(envBytes, zstdMagic) {
+		return entry, fmt.Errorf("%w: missing type discriminator", ErrUnknownType)
+	}
+
+	var env Envelope
+	if err = env.UnmarshalBinary(envBytes); err != nil {
+		t.raw.logDecodeFailure("envelope", string(envBytes), err)
+		return entry, err
+	}
+
+	if env.ContentEncoding != ContentEncodingZSTD {
+		return entry, fmt.Errorf("%w: unsupported content encoding %q", ErrInvalidEnvelope, env.ContentEncoding)
+	}
+
+	if err = env.verifyChecksum(); err != nil {
+		return entry, err
+	}
+
+	registered, ok := t.registry.lookupName(env.Type)
+	if !ok {
+		if env.Type == "" {
+			return entry, fmt.Errorf("%w: missing type discriminator", ErrUnknownType)
+		}
+
+		return entry, fmt.Errorf("%w: %q", ErrUnknownType, env.Type)
+	}
+
+	jsonBytes, err := t.raw.decodeFrame(env.Payload, env.SchemaVersion)
+	if err != nil {
+		return entry, err
+	}
+
+	entry, err = registered.decode(jsonBytes, t.raw.opts.json)
+	if err != nil {
+		t.raw.logDecodeFailure("json", string(jsonBytes), err)
+		return entry, errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,131 @@
+package compressjson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type event interface {
+	Kind() string
+}
+
+type userCreated struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (userCreated) Kind() string { return "user.created" }
+
+type userDeleted struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (*userDeleted) Kind() string { return "user.deleted" }
+
+// TestPolymorphicTranscoder is the table-driven test for NewPolymorphicTranscoder. It verifies that
+// registered value and pointer types round trip behind the interface with their concrete type,
+// and that unregistered types and discriminators are rejected with ErrUnknownType.
+func TestPolymorphicTranscoder(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry[event]()
+	assert.NoError(t, Register[event, userCreated](reg, "user.created"))
+	assert.NoError(t, Register[event, *userDeleted](reg, "user.deleted"))
+
+	tr := NewPolymorphicTranscoder(reg)
+
+	other := NewRegistry[event]()
+	assert.NoError(t, Register[event, userCreated](other, "created.v2"))
+	foreign, err := NewPolymorphicTranscoder(other).Encode(userCreated{ID: 9})
+	assert.NoError(t, err)
+
+	bare, err := NewTranscoder[userCreated]().Encode(userCreated{ID: 9})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		input   event
+		encoded string
+		wantErr bool
+	}{
+		{name: "Value type", input: userCreated{ID: 1, Name: "Alice"}},
+		{name: "Pointer type", input: &userDeleted{ID: 2, Reason: "requested"}},
+		{name: "Unregistered type", input: &userCreated{ID: 3}, wantErr: true},
+		{name: "Unknown discriminator", encoded: foreign, wantErr: true},
+		{name: "Missing discriminator", encoded: bare, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.encoded
+			if tt.input != nil {
+				var err error
+				encoded, err = tr.Encode(tt.input)
+
+				if tt.wantErr {
+					assert.ErrorIs(t, err, ErrUnknownType)
+					return
+				}
+
+				assert.NoError(t, err)
+			}
+
+			got, err := tr.Decode(encoded)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnknownType)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.IsType(t, tt.input, got)
+			assert.Equal(t, tt.input, got)
+			assert.Equal(t, tt.input.Kind(), got.Kind())
+		})
+	}
+}
+
+// TestRegisterErrors verifies that invalid registrations are rejected.
+func TestRegisterErrors(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry[event]()
+	assert.NoError(t, Register[event, userCreated](reg, "user.created"))
+
+	assert.Error(t, Register[event, userDeleted](reg, "user.deleted"), "Methods with pointer receivers are missing on the value type")
+	assert.Error(t, Register[event, *userDeleted](reg, ""), "Names must not be empty")
+	assert.Error(t, Register[event, *userDeleted](reg, "user.created"), "Names must be unique")
+	assert.Error(t, Register[event, userCreated](reg, "user.created.v2"), "Types must be unique")
+}
+
+// TestPolymorphicTranscoderLogsEnvelope verifies that a malformed envelope is logged with the
+// decoded envelope bytes as hexadecimal, not with the Base64 input of the previous stage.
+func TestPolymorphicTranscoderLogsEnvelope(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	reg := NewRegistry[event]()
+	assert.NoError(t, Register[event, userCreated](reg, "user.created"))
+
+	tr := NewPolymorphicTranscoder(reg, WithLogger(logger))
+
+	envBytes := append(append([]byte(nil), envelopeMagic...), 0xff, 0xff)
+	encoded, err := base64Stage.Encode(envBytes)
+	assert.NoError(t, err)
+
+	_, err = tr.Decode(encoded)
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	out := buf.String()
+	assert.Contains(t, out, "stage=envelope")
+	assert.Contains(t, out, "input_len="+strconv.Itoa(len(envBytes)))
+	assert.Contains(t, out, "input_prefix="+hex.EncodeToString(envBytes))
+}
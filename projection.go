@@ -0,0 +1,172 @@
+package compressjson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/spacemagneto/compressjson/lib"
+)
+
+// ErrMissingField reports that a field of the projection type is absent from the decoded document
+// while DecodeAs runs with WithRequiredFields.
+var ErrMissingField = errors.New("missing field")
+
+// ProjectionOption configures DecodeAs.
+type ProjectionOption func(*projectionConfig)
+
+// projectionConfig holds the settings applied by ProjectionOption values.
+type projectionConfig struct {
+	required bool
+}
+
+// WithRequiredFields makes DecodeAs fail with ErrMissingField when a top-level field of the
+// projection type, including fields promoted from embedded structs, is not a member of the
+// decoded JSON object. It guards against projections that silently drift from the encoded type,
+// for example after a field was renamed. Fields tagged with `json:"-"` are never required.
+func WithRequiredFields() ProjectionOption {
+	return func(c *projectionConfig) {
+		c.required = true
+	}
+}
+
+// DecodeAs decodes a string produced by tr into a value of a different type U, usually a small
+// struct holding only the fields a service needs. It runs the same stages as tr.Decode, including
+// schema migrations, but unmarshals into U, so the full T is never allocated. Members of the
+// document without a field in U are ignored, even when tr disallows unknown fields; the other
+// JSON options of tr apply. Transcoders that are not created by this package are decoded with
+// the default pipeline settings.
+func DecodeAs[T, U any](tr Transcoder[T], src string, opts ...ProjectionOption) (U, error) {
+	var cfg projectionConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var entry U
+
+	t := jsonStagesOf(tr)
+
+	compressedBytes, err := t.binaryTranscoder.Decode(src)
+	if err != nil {
+		t.logDecodeFailure("base64", src, err)
+		return entry, errors.Join(ErrDecodeBase64, err)
+	}
+
+	frame, version, err := splitVersionedFrame(compressedBytes)
+	if err != nil {
+		t.logDecodeFailure("envelope", string(compressedBytes), err)
+		return entry, err
+	}
+
+	jsonBytes, err := t.frameJSON(frame, version)
+	if err != nil {
+		return entry, err
+	}
+
+	if cfg.required {
+		if err = checkRequiredFields(jsonBytes, reflect.TypeFor[U]()); err != nil {
+			return entry, err
+		}
+	}
+
+	jsonOpts := t.opts.json
+	jsonOpts.DisallowUnknownFields = false
+
+	entry, err = lib.NewJSONTranscoderWithOptions[U](jsonOpts).Unmarshal(jsonBytes)
+	if err != nil {
+		t.logDecodeFailure("json", string(jsonBytes), err)
+		return entry, errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	return entry, nil
+}
+
+// checkRequiredFields verifies that the JSON object src has a member for every field of the struct
+// type typ. Members match field names case-insensitively, like during unmarshalling.
+// Types other than structs and pointers to structs have no required fields.
+func checkRequiredFields(src []byte, typ reflect.Type) error {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(src, &members); err != nil {
+		return errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	var missing []string
+	for _, name := range jsonFieldNames(typ) {
+		if !hasMember(members, name) {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingField, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// jsonFieldNames returns the JSON member names of the exported fields of the struct type typ.
+// Untagged embedded structs contribute their own fields.
+func jsonFieldNames(typ reflect.Type) []string {
+	var names []string
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				names = append(names, jsonFieldNames(embedded)...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// hasMember reports whether members holds name, preferring an exact match.
+func hasMember(members map[string]json.RawMessage, name string) bool {
+	if _, ok := members[name]; ok {
+		return true
+	}
+
+	for member := range members {
+		if strings.EqualFold(member, name) {
+			return true
+		}
+	}
+
+	return false
+}
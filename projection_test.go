@@ -0,0 +1,94 @@
+package compressjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mocks "github.com/spacemagneto/compressjson/mocks"
+)
+
+type userName struct {
+	Name string `json:"name"`
+}
+
+type userContact struct {
+	userName
+	Email string `json:"email"`
+	Phone string `json:"phone,omitempty"`
+	Note  string `json:"-"`
+}
+
+// TestDecodeAs is the table-driven test for DecodeAs. It covers projections with and without
+// WithRequiredFields, migrated input and invalid input.
+func TestDecodeAs(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: "Alice", Email: "alice@example.com", Age: 30}
+
+	tr := NewTranscoder[user](WithDisallowUnknownFields())
+	encoded, err := tr.Encode(value)
+	assert.NoError(t, err)
+
+	t.Run("Subset of fields", func(t *testing.T) {
+		got, err := DecodeAs[user, userName](tr, encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, userName{Name: "Alice"}, got)
+	})
+
+	t.Run("Required fields present", func(t *testing.T) {
+		got, err := DecodeAs[user, userName](tr, encoded, WithRequiredFields())
+		assert.NoError(t, err)
+		assert.Equal(t, userName{Name: "Alice"}, got)
+	})
+
+	t.Run("Missing field is zero", func(t *testing.T) {
+		got, err := DecodeAs[user, userContact](tr, encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, userContact{userName: userName{Name: "Alice"}, Email: "alice@example.com"}, got)
+	})
+
+	t.Run("Missing field is required", func(t *testing.T) {
+		_, err := DecodeAs[user, userContact](tr, encoded, WithRequiredFields())
+		assert.ErrorIs(t, err, ErrMissingField)
+		assert.ErrorContains(t, err, "phone")
+		assert.NotContains(t, err.Error(), "Note")
+	})
+
+	t.Run("Map projection", func(t *testing.T) {
+		got, err := DecodeAs[user, map[string]any](tr, encoded, WithRequiredFields())
+		assert.NoError(t, err)
+		assert.Equal(t, "Alice", got["name"])
+	})
+
+	t.Run("Migrated input", func(t *testing.T) {
+		old, err := NewTranscoder[userV0]().Encode(userV0{ID: 2, FullName: "Bob"})
+		assert.NoError(t, err)
+
+		migrating := NewTranscoder[user](WithSchemaVersion(1), WithMigration(0, renameFullName))
+
+		got, err := DecodeAs[user, userName](migrating, old)
+		assert.NoError(t, err)
+		assert.Equal(t, userName{Name: "Bob"}, got)
+	})
+
+	t.Run("Foreign transcoder", func(t *testing.T) {
+		mock := mocks.NewMockTranscoder[user](t)
+
+		got, err := DecodeAs[user, userName](mock, encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, userName{Name: "Alice"}, got)
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := DecodeAs[user, userName](tr, "!!!")
+		assert.ErrorIs(t, err, ErrDecodeBase64)
+	})
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		_, err := DecodeAs[user, struct {
+			Name int `json:"name"`
+		}](tr, encoded)
+		assert.ErrorIs(t, err, ErrUnmarshalJSON)
+	})
+}
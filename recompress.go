@@ -0,0 +1,80 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Recompress decompresses a string produced by Encode and compresses the JSON again with the
+// level and frame checksum configured by WithCompressionLevel and WithChecksum, for example to
+// shrink stored values with a slower level after the fact. The JSON is not unmarshaled or migrated,
+// and envelope fields such as the schema version and type are preserved. An envelope checksum is
+// written when the value had one or WithChecksum selects ChecksumCRC32C. Values written by
+// NewParallelTranscoder become a single frame.
+func Recompress(s string, opts ...Option) (string, error) {
+	t := newTranscoder[[]byte](opts...)
+
+	compressedBytes, err := t.binaryTranscoder.Decode(s)
+	if err != nil {
+		return "", errors.Join(ErrDecodeBase64, err)
+	}
+
+	withChecksum := t.opts.checksum&ChecksumCRC32C != 0
+
+	if !bytes.HasPrefix(compressedBytes, envelopeMagic) {
+		frame, err := t.recompressFrame(compressedBytes)
+		if err != nil {
+			return "", err
+		}
+
+		if frame, err = versionedFrame(frame, 0, withChecksum); err != nil {
+			return "", err
+		}
+
+		return t.binaryTranscoder.Encode(frame)
+	}
+
+	var env Envelope
+	if err = env.UnmarshalBinary(compressedBytes); err != nil {
+		return "", err
+	}
+
+	if env.ContentEncoding != ContentEncodingZSTD {
+		return "", fmt.Errorf("%w: unsupported content encoding %q", ErrInvalidEnvelope, env.ContentEncoding)
+	}
+
+	if err = env.verifyChecksum(); err != nil {
+		return "", err
+	}
+
+	if env.Payload, err = t.recompressFrame(env.Payload); err != nil {
+		return "", err
+	}
+
+	if withChecksum || env.Checksum != 0 {
+		env.Checksum = payloadChecksum(env.Payload)
+	}
+
+	envBytes, err := env.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+
+	return t.binaryTranscoder.Encode(envBytes)
+}
+
+// recompressFrame decompresses src and compresses the content again with the level of the transcoder.
+func (t *transcoder[T]) recompressFrame(src []byte) ([]byte, error) {
+	jsonBytes, err := t.standardTranscoder.Decompress(src)
+	if err != nil {
+		return nil, decompressError(err)
+	}
+
+	frame, err := t.standardTranscoder.Compress(jsonBytes)
+	if err != nil {
+		return nil, errors.Join(ErrCompress, err)
+	}
+
+	return frame, nil
+}
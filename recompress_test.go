@@ -0,0 +1,126 @@
+package compressjson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompressionLevel verifies that values encoded with any level decode with the default transcoder.
+func TestCompressionLevel(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: strings.Repeat("Alice ", 200), Email: "alice@example.com"}
+
+	fastest, err := NewTranscoder[user]().Encode(value)
+	assert.NoError(t, err)
+
+	best, err := NewTranscoder[user](WithCompressionLevel(zstd.SpeedBestCompression)).Encode(value)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(best), len(fastest))
+
+	got, err := NewTranscoder[user]().Decode(best)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+}
+
+// TestRecompress is the table-driven test for Recompress. It verifies that bare frames, versioned
+// values, typed envelopes and multi-frame values keep decoding to the same value, and that invalid
+// input is rejected.
+func TestRecompress(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: strings.Repeat("Alice ", 200), Email: "alice@example.com"}
+
+	bare, err := NewTranscoder[user]().Encode(value)
+	assert.NoError(t, err)
+
+	versioned := NewTranscoder[user](WithSchemaVersion(2))
+	versionedValue, err := versioned.Encode(value)
+	assert.NoError(t, err)
+
+	reg := NewRegistry[event]()
+	assert.NoError(t, Register[event, userCreated](reg, "user.created"))
+
+	typed := NewPolymorphicTranscoder(reg)
+	typedValue, err := typed.Encode(userCreated{ID: 1, Name: "Alice"})
+	assert.NoError(t, err)
+
+	parallel := NewParallelTranscoder[int](WithParallelBlockSize(2))
+	parallelValue, err := parallel.Encode([]int{1, 2, 3, 4, 5})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		input   string
+		decode  func(string) (any, error)
+		want    any
+		wantErr error
+	}{
+		{name: "Bare frame", input: bare, want: value, decode: func(s string) (any, error) { return NewTranscoder[user]().Decode(s) }},
+		{name: "Versioned value", input: versionedValue, want: value, decode: func(s string) (any, error) { return versioned.Decode(s) }},
+		{name: "Typed envelope", input: typedValue, want: userCreated{ID: 1, Name: "Alice"}, decode: func(s string) (any, error) { return typed.Decode(s) }},
+		{name: "Several frames", input: parallelValue, want: []int{1, 2, 3, 4, 5}, decode: func(s string) (any, error) { return parallel.Decode(s) }},
+		{name: "Invalid Base64", input: "!!!", wantErr: ErrDecodeBase64},
+		{name: "Not a frame", input: "aGVsbG8gd29ybGQ=", wantErr: ErrDecompress},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Recompress(tt.input, WithCompressionLevel(zstd.SpeedBestCompression))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			decoded, err := tt.decode(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, decoded)
+
+			before, err := Inspect(tt.input)
+			assert.NoError(t, err)
+
+			after, err := Inspect(got)
+			assert.NoError(t, err)
+			assert.Equal(t, before.Type, after.Type)
+			assert.Equal(t, before.SchemaVersion, after.SchemaVersion)
+			assert.Equal(t, 1, after.Frames)
+		})
+	}
+}
+
+// TestRecompressChecksum verifies that Recompress adds the checksums selected by WithChecksum
+// and keeps an existing envelope checksum valid.
+func TestRecompressChecksum(t *testing.T) {
+	t.Parallel()
+
+	value := user{ID: 1, Name: "Alice"}
+
+	bare, err := NewTranscoder[user]().Encode(value)
+	assert.NoError(t, err)
+
+	withCRC, err := Recompress(bare, WithChecksum(ChecksumCRC32C))
+	assert.NoError(t, err)
+
+	info, err := Inspect(withCRC)
+	assert.NoError(t, err)
+	assert.True(t, info.EnvelopeChecksum)
+	assert.False(t, info.Checksum)
+
+	recompressed, err := Recompress(withCRC, WithCompressionLevel(zstd.SpeedBestCompression))
+	assert.NoError(t, err)
+
+	info, err = Inspect(recompressed)
+	assert.NoError(t, err)
+	assert.True(t, info.EnvelopeChecksum)
+	assert.True(t, info.Checksum)
+
+	got, err := NewTranscoder[user]().Decode(recompressed)
+	assert.NoError(t, err)
+	assert.Equal(t, value, got)
+}
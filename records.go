@@ -0,0 +1,201 @@
+package compressjson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// RecordWriter writes values of type T as newline-delimited JSON (NDJSON) into a single Z - standard
+// stream, so that redundancy between records, such as repeated keys, is compressed only once.
+// With WithBase64Records the compressed stream is additionally encoded to standard Base64.
+//
+// A RecordWriter is not safe for concurrent use. Close must be called to complete the stream.
+type RecordWriter[T any] struct {
+	t       *transcoder[T]
+	encoder *zstd.Encoder
+	text    io.WriteCloser
+	line    []byte
+	err     error
+}
+
+// NewRecordWriter creates a RecordWriter that writes the compressed stream to w.
+// The JSON options of opts, such as WithCanonicalJSON, apply to every record.
+func NewRecordWriter[T any](w io.Writer, opts ...Option) (*RecordWriter[T], error) {
+	rw := &RecordWriter[T]{t: newTranscoder[T](opts...)}
+
+	if rw.t.opts.base64Records {
+		rw.text = rw.t.binaryTranscoder.NewEncoder(w)
+		w = rw.text
+	}
+
+	encoder, err := rw.t.standardTranscoder.NewWriter(w)
+	if err != nil {
+		return nil, errors.Join(ErrCompress, err)
+	}
+
+	rw.encoder = encoder
+
+	return rw, nil
+}
+
+// Write appends v to the stream as a single line. After a failed write the stream is unusable,
+// and every further call returns the same error.
+func (w *RecordWriter[T]) Write(v T) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	jsonBytes, err := w.t.jsonTranscoder.Marshal(v)
+	if err != nil {
+		w.t.logEncodeFailure("json", v, err)
+		return errors.Join(ErrMarshalJSON, err)
+	}
+
+	w.line = append(append(w.line[:0], jsonBytes...), '\n')
+	if _, err = w.encoder.Write(w.line); err != nil {
+		w.err = errors.Join(ErrCompress, err)
+	}
+
+	return w.err
+}
+
+// Close completes the compressed stream and, with WithBase64Records, flushes the final Base64 block.
+// It does not close the underlying writer.
+func (w *RecordWriter[T]) Close() error {
+	err := w.encoder.Close()
+	if err != nil {
+		err = errors.Join(ErrCompress, err)
+	}
+
+	if w.text != nil {
+		err = errors.Join(err, w.text.Close())
+	}
+
+	if w.err == nil {
+		w.err = errors.New("record writer is closed")
+	}
+
+	return err
+}
+
+// RecordReader reads values written by a RecordWriter. Records are read one at a time, so streams
+// of any size are processed in constant memory. Use Next and Record in a loop and check Err
+// afterwards, or range over All.
+//
+// A RecordReader is not safe for concurrent use. Call Close when done; it releases the decoder
+// and is safe to call after the stream has been read completely.
+type RecordReader[T any] struct {
+	t       *transcoder[T]
+	decoder *zstd.Decoder
+	lines   *bufio.Reader
+	line    int
+	record  T
+	err     error
+}
+
+// NewRecordReader creates a RecordReader that reads the compressed stream from r.
+// It must be created with the same WithBase64Records setting as the writer. The JSON options
+// of opts, such as WithDisallowUnknownFields, apply to every record.
+func NewRecordReader[T any](r io.Reader, opts ...Option) (*RecordReader[T], error) {
+	rr := &RecordReader[T]{t: newTranscoder[T](opts...)}
+
+	if rr.t.opts.base64Records {
+		r = rr.t.binaryTranscoder.NewDecoder(r)
+	}
+
+	decoder, err := rr.t.standardTranscoder.NewReader(r)
+	if err != nil {
+		return nil, errors.Join(ErrDecompress, err)
+	}
+
+	rr.decoder = decoder
+	rr.lines = bufio.NewReader(decoder)
+
+	return rr, nil
+}
+
+// Next advances to the next record and reports whether one is available. It returns false at the
+// end of the stream and on the first error, which is then reported by Err.
+func (r *RecordReader[T]) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	for {
+		line, err := r.lines.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			r.err = decompressError(err)
+			r.decoder.Close()
+
+			return false
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			r.line++
+
+			record, decodeErr := r.t.decodeJSON(line)
+			if decodeErr != nil {
+				r.err = fmt.Errorf("record %d: %w", r.line, decodeErr)
+				return false
+			}
+
+			r.record = record
+
+			return true
+		}
+
+		if err != nil {
+			r.err = io.EOF
+			r.decoder.Close()
+
+			return false
+		}
+	}
+}
+
+// Record returns the record read by the last successful call to Next.
+func (r *RecordReader[T]) Record() T {
+	return r.record
+}
+
+// Err returns the first error encountered by Next, or nil if the stream ended normally.
+func (r *RecordReader[T]) Err() error {
+	if errors.Is(r.err, io.EOF) {
+		return nil
+	}
+
+	return r.err
+}
+
+// Close stops reading and releases the decoder. It does not close the underlying reader.
+func (r *RecordReader[T]) Close() {
+	if r.err == nil {
+		r.err = io.EOF
+	}
+
+	r.decoder.Close()
+}
+
+// All returns an iterator over the remaining records. A failure is yielded once together with the
+// zero value of T and ends the iteration. Breaking out of the loop does not release the decoder;
+// call Close in that case.
+func (r *RecordReader[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for r.Next() {
+			if !yield(r.record, nil) {
+				return
+			}
+		}
+
+		if err := r.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package compressjson
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecordStream is the table-driven test for RecordWriter[T] and RecordReader[T]. It writes
+// a stream of records, verifies that the shared stream is much smaller than encoding every record
+// separately, and reads the records back both with Next and through the All iterator.
+func TestRecordStream(t *testing.T) {
+	t.Parallel()
+
+	records := make([]user, 1000)
+	for i := range records {
+		records[i] = user{ID: i, Name: fmt.Sprintf("user-%d", i), Email: fmt.Sprintf("user-%d@example.com", i), Age: i % 90}
+	}
+
+	var separate int
+	for _, r := range records {
+		encoded, err := NewTranscoder[user]().Encode(r)
+		assert.NoError(t, err)
+		separate += len(encoded)
+	}
+
+	cases := []struct {
+		name    string
+		opts    []Option
+		records []user
+	}{
+		{name: "Binary stream", records: records},
+		{name: "Base64 stream", opts: []Option{WithBase64Records()}, records: records},
+		{name: "Empty stream", records: nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w, err := NewRecordWriter[user](&buf, tt.opts...)
+			assert.NoError(t, err)
+
+			for _, r := range tt.records {
+				assert.NoError(t, w.Write(r))
+			}
+
+			assert.NoError(t, w.Close())
+			assert.Error(t, w.Write(user{}), "Writing to a closed stream must fail")
+
+			if len(tt.records) > 0 {
+				assert.Less(t, buf.Len()*4, separate, "A shared stream must exploit redundancy between records")
+			}
+
+			data := buf.Bytes()
+
+			r, err := NewRecordReader[user](bytes.NewReader(data), tt.opts...)
+			assert.NoError(t, err)
+
+			var got []user
+			for r.Next() {
+				got = append(got, r.Record())
+			}
+
+			assert.NoError(t, r.Err())
+			assert.Equal(t, tt.records, got)
+			r.Close()
+
+			r, err = NewRecordReader[user](bytes.NewReader(data), tt.opts...)
+			assert.NoError(t, err)
+
+			got = nil
+			for record, err := range r.All() {
+				assert.NoError(t, err)
+				got = append(got, record)
+			}
+
+			assert.Equal(t, tt.records, got)
+			r.Close()
+		})
+	}
+}
+
+// TestRecordReaderErrors verifies that invalid records and corrupted streams are reported
+// through Err and the All iterator.
+func TestRecordReaderErrors(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	w, err := NewRecordWriter[any](&buf)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(map[string]any{"id": 1}))
+	assert.NoError(t, w.Write("not a user"))
+	assert.NoError(t, w.Close())
+
+	r, err := NewRecordReader[user](bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	defer r.Close()
+
+	assert.True(t, r.Next())
+	assert.Equal(t, user{ID: 1}, r.Record())
+	assert.False(t, r.Next())
+	assert.ErrorIs(t, r.Err(), ErrUnmarshalJSON)
+	assert.ErrorContains(t, r.Err(), "record 2")
+
+	corrupted, err := NewRecordReader[user](bytes.NewReader([]byte("definitely not zstd")))
+	assert.NoError(t, err)
+	defer corrupted.Close()
+
+	var failures int
+	for _, err := range corrupted.All() {
+		assert.ErrorIs(t, err, ErrDecompress)
+		failures++
+	}
+
+	assert.Equal(t, 1, failures)
+}
@@ -0,0 +1,82 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/goccy/go-json"
+)
+
+// ErrMigration reports that encoded data could not be brought to the schema version
+// of the decoding transcoder, either because a migration step failed or is not registered,
+// or because the data was written with a newer schema version.
+var ErrMigration = errors.New("failed to migrate schema")
+
+// MigrationFunc upgrades the JSON form of a value by exactly one schema version.
+// It receives the document written with version n and returns the document for version n+1.
+type MigrationFunc func(json.RawMessage) (json.RawMessage, error)
+
+// versionedFrame wraps a compressed frame into a binary envelope recording the schema version
+// and, if requested, the checksum of the frame. Values written with version zero and without
+// a checksum are stored as bare frames, which keeps the output of unversioned transcoders
+// unchanged and lets every version read them.
+func versionedFrame(frame []byte, version uint32, checksum bool) ([]byte, error) {
+	if version == 0 && !checksum {
+		return frame, nil
+	}
+
+	env := Envelope{SchemaVersion: version, ContentEncoding: ContentEncodingZSTD, Payload: frame}
+	if checksum {
+		env.Checksum = payloadChecksum(frame)
+	}
+
+	return env.MarshalBinary()
+}
+
+// splitVersionedFrame reverses versionedFrame and returns the compressed frame
+// together with the schema version it was written with, after verifying its checksum.
+func splitVersionedFrame(src []byte) ([]byte, uint32, error) {
+	if !bytes.HasPrefix(src, envelopeMagic) {
+		return src, 0, nil
+	}
+
+	var env Envelope
+	if err := env.UnmarshalBinary(src); err != nil {
+		return nil, 0, err
+	}
+
+	if env.ContentEncoding != ContentEncodingZSTD {
+		return nil, 0, fmt.Errorf("%w: unsupported content encoding %q", ErrInvalidEnvelope, env.ContentEncoding)
+	}
+
+	if err := env.verifyChecksum(); err != nil {
+		return nil, 0, err
+	}
+
+	return env.Payload, env.SchemaVersion, nil
+}
+
+// migrate applies the registered migrations to a JSON document written with the given
+// schema version until it matches the schema version of the transcoder.
+func (t *transcoder[T]) migrate(src []byte, version uint32) ([]byte, error) {
+	if version > t.opts.schemaVersion {
+		return nil, fmt.Errorf("%w: data version %d is newer than %d", ErrMigration, version, t.opts.schemaVersion)
+	}
+
+	for ; version < t.opts.schemaVersion; version++ {
+		fn, ok := t.opts.migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: no migration from version %d", ErrMigration, version)
+		}
+
+		migrated, err := fn(src)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("%w: from version %d", ErrMigration, version), err)
+		}
+
+		src = migrated
+	}
+
+	return src, nil
+}
@@ -0,0 +1,125 @@
+package compressjson
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// userV0 is the original shape of user, before the name field was renamed.
+type userV0 struct {
+	ID       int    `json:"id"`
+	FullName string `json:"full_name"`
+}
+
+// renameFullName is the migration from version 0 to 1: full_name becomes name.
+func renameFullName(src json.RawMessage) (json.RawMessage, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+
+	doc["name"] = doc["full_name"]
+	delete(doc, "full_name")
+
+	return json.Marshal(doc)
+}
+
+// addEmail is the migration from version 1 to 2: email becomes mandatory.
+func addEmail(src json.RawMessage) (json.RawMessage, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+
+	if _, ok := doc["email"]; !ok {
+		doc["email"] = "unknown@example.com"
+	}
+
+	return json.Marshal(doc)
+}
+
+// TestSchemaMigration is the table-driven test for schema versioning. Values are encoded with an
+// older transcoder and decoded with the current one, which must run the migration chain from the
+// recorded version or fail with ErrMigration.
+func TestSchemaMigration(t *testing.T) {
+	t.Parallel()
+
+	current := NewTranscoder[user](
+		WithSchemaVersion(2),
+		WithMigration(0, renameFullName),
+		WithMigration(1, addEmail),
+	)
+
+	v0, err := NewTranscoder[userV0]().Encode(userV0{ID: 1, FullName: "Alice"})
+	assert.NoError(t, err)
+
+	v1, err := NewTranscoder[user](WithSchemaVersion(1)).Encode(user{ID: 2, Name: "Bob"})
+	assert.NoError(t, err)
+
+	v2, err := current.Encode(user{ID: 3, Name: "Carol", Email: "carol@example.com"})
+	assert.NoError(t, err)
+
+	v3, err := NewTranscoder[user](WithSchemaVersion(3)).Encode(user{ID: 4})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		tr      Transcoder[user]
+		input   string
+		want    user
+		wantErr bool
+	}{
+		{name: "Unversioned data runs the whole chain", tr: current, input: v0, want: user{ID: 1, Name: "Alice", Email: "unknown@example.com"}},
+		{name: "Intermediate version runs the rest of the chain", tr: current, input: v1, want: user{ID: 2, Name: "Bob", Email: "unknown@example.com"}},
+		{name: "Current version needs no migration", tr: current, input: v2, want: user{ID: 3, Name: "Carol", Email: "carol@example.com"}},
+		{name: "Newer version is rejected", tr: current, input: v3, wantErr: true},
+		{name: "Missing migration", tr: NewTranscoder[user](WithSchemaVersion(2), WithMigration(1, addEmail)), input: v0, wantErr: true},
+		{name: "Unversioned transcoder rejects versioned data", tr: NewTranscoder[user](), input: v1, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tr.Decode(tt.input)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrMigration)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestSchemaVersionFormat verifies that version zero keeps the bare frame format, that other
+// versions are wrapped into an envelope, and that migration errors keep their cause.
+func TestSchemaVersionFormat(t *testing.T) {
+	t.Parallel()
+
+	bare, err := newTranscoder[user]().encodeBytes(user{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(bare, zstdMagic))
+
+	versioned, err := newTranscoder[user](WithSchemaVersion(7)).encodeBytes(user{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(versioned, envelopeMagic))
+
+	frame, version, err := splitVersionedFrame(versioned)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(7), version)
+	assert.Equal(t, bare, frame)
+
+	cause := errors.New("boom")
+	failing := newTranscoder[user](WithSchemaVersion(1), WithMigration(0, func(json.RawMessage) (json.RawMessage, error) {
+		return nil, cause
+	}))
+
+	_, err = failing.decodeBytes(bare)
+	assert.ErrorIs(t, err, ErrMigration)
+	assert.ErrorIs(t, err, cause)
+}
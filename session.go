@@ -0,0 +1,416 @@
+package compressjson
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxCookieSize is the default limit for the name and value of a single session cookie.
+// Browsers are required to accept at least 4096 bytes per cookie including its attributes,
+// so a margin is left for Path, Domain, Expires and the other attributes.
+const DefaultMaxCookieSize = 3800
+
+var (
+	// ErrNoSession reports that the request does not carry a session cookie.
+	ErrNoSession = errors.New("no session")
+
+	// ErrInvalidSession reports a session cookie that is malformed, was sealed with an unknown key
+	// or has been tampered with.
+	ErrInvalidSession = errors.New("invalid session")
+
+	// ErrSessionExpired reports a session cookie that is older than the configured maximum age.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// SessionOption configures optional behavior of a SessionStore.
+type SessionOption func(*sessionConfig)
+
+// sessionConfig holds the optional settings of a SessionStore.
+type sessionConfig struct {
+	encryptionKeys [][]byte
+	signingKeys    [][]byte
+	maxAge         time.Duration
+	maxCookieSize  int
+	cookie         http.Cookie
+}
+
+// WithEncryptionKeys seals session cookies with AES-GCM, which provides both confidentiality
+// and integrity. Every key must be 16, 24 or 32 bytes long. The first key seals new cookies,
+// while all keys are tried when opening one, which allows keys to be rotated without logging
+// users out: put the new key first and keep the old one until its cookies have been re-saved.
+// Encryption takes precedence over WithSigningKeys.
+func WithEncryptionKeys(keys ...[]byte) SessionOption {
+	return func(c *sessionConfig) {
+		c.encryptionKeys = keys
+	}
+}
+
+// WithSigningKeys authenticates session cookies with HMAC-SHA256 without encrypting them,
+// so clients can read but not modify their session. Key rotation works as for WithEncryptionKeys.
+func WithSigningKeys(keys ...[]byte) SessionOption {
+	return func(c *sessionConfig) {
+		c.signingKeys = keys
+	}
+}
+
+// WithSessionMaxAge limits the lifetime of a session. The limit is set as the cookie Max-Age
+// and also enforced on the server from the issue time sealed into the cookie, so a client cannot
+// extend a session by keeping an old cookie around. Zero means a browser-session cookie without a limit.
+func WithSessionMaxAge(maxAge time.Duration) SessionOption {
+	return func(c *sessionConfig) {
+		c.maxAge = maxAge
+	}
+}
+
+// WithMaxCookieSize sets the size above which a session is split across several cookies.
+func WithMaxCookieSize(n int) SessionOption {
+	return func(c *sessionConfig) {
+		c.maxCookieSize = n
+	}
+}
+
+// WithCookieTemplate replaces the default cookie attributes (Path=/, Secure, HttpOnly, SameSite=Lax)
+// with the Path, Domain, Secure, HttpOnly, SameSite and Partitioned fields of template. All other
+// fields, including Name, Value and the expiration fields, are ignored; use WithSessionMaxAge instead.
+func WithCookieTemplate(template http.Cookie) SessionOption {
+	return func(c *sessionConfig) {
+		c.cookie = http.Cookie{
+			Path:        template.Path,
+			Domain:      template.Domain,
+			Secure:      template.Secure,
+			HttpOnly:    template.HttpOnly,
+			SameSite:    template.SameSite,
+			Partitioned: template.Partitioned,
+		}
+	}
+}
+
+// SessionStore keeps a value of type T in HTTP cookies. Values are encoded with a transcoder,
+// optionally encrypted or signed, and split across several cookies when they exceed the cookie
+// size limit. SessionStore is stateless on the server and safe for concurrent use.
+type SessionStore[T any] struct {
+	name       string
+	transcoder Transcoder[T]
+	aeads      []cipher.AEAD
+	signKeys   [][]byte
+	maxAge     time.Duration
+	maxSize    int
+	cookie     http.Cookie
+	now        func() time.Time
+}
+
+// NewSessionStore creates a store that keeps sessions in cookies named after name.
+// Values are converted with transcoder; if it is nil, a default NewTranscoder[T] is used.
+// Without encryption or signing keys, cookies are only encoded and must not be trusted.
+func NewSessionStore[T any](name string, transcoder Transcoder[T], opts ...SessionOption) (*SessionStore[T], error) {
+	if name == "" {
+		return nil, errors.New("session cookie name must not be empty")
+	}
+
+	cfg := sessionConfig{
+		maxCookieSize: DefaultMaxCookieSize,
+		cookie:        http.Cookie{Path: "/", Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if transcoder == nil {
+		transcoder = NewTranscoder[T]()
+	}
+
+	s := &SessionStore[T]{
+		name:       name,
+		transcoder: transcoder,
+		signKeys:   cfg.signingKeys,
+		maxAge:     cfg.maxAge,
+		maxSize:    cfg.maxCookieSize - len(name) - len("_00="),
+		cookie:     cfg.cookie,
+		now:        time.Now,
+	}
+
+	for _, key := range cfg.encryptionKeys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.Join(errors.New("invalid session encryption key"), err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.Join(errors.New("invalid session encryption key"), err)
+		}
+
+		s.aeads = append(s.aeads, aead)
+	}
+
+	for _, key := range cfg.signingKeys {
+		if len(key) == 0 {
+			return nil, errors.New("session signing key must not be empty")
+		}
+	}
+
+	return s, nil
+}
+
+// Load reads the session from the cookies of r. It returns ErrNoSession when the request
+// carries no session, and an error wrapping ErrInvalidSession or ErrSessionExpired when the
+// cookies cannot be trusted.
+func (s *SessionStore[T]) Load(r *http.Request) (T, error) {
+	var entry T
+
+	sealed, err := s.read(r)
+	if err != nil {
+		return entry, err
+	}
+
+	encoded, err := s.open(sealed)
+	if err != nil {
+		return entry, err
+	}
+
+	return s.transcoder.Decode(encoded)
+}
+
+// Save writes v to the response as one or more session cookies, sealed with the primary key.
+// Saving a session loaded with an older key therefore rotates it to the current key.
+// Cookies of a previous, differently chunked session found in r are expired.
+func (s *SessionStore[T]) Save(w http.ResponseWriter, r *http.Request, v T) error {
+	encoded, err := s.transcoder.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := s.seal(encoded)
+	if err != nil {
+		return err
+	}
+
+	written := make(map[string]bool)
+
+	if len(sealed) <= s.maxSize {
+		http.SetCookie(w, s.newCookie(s.name, sealed))
+		written[s.name] = true
+	} else {
+		chunks, err := chunkString(sealed, s.maxSize)
+		if err != nil {
+			return err
+		}
+
+		for i, c := range chunks {
+			name := s.chunkName(i)
+			http.SetCookie(w, s.newCookie(name, c))
+			written[name] = true
+		}
+	}
+
+	for _, name := range s.present(r) {
+		if !written[name] {
+			http.SetCookie(w, s.expiredCookie(name))
+		}
+	}
+
+	return nil
+}
+
+// Clear expires every session cookie found in r.
+func (s *SessionStore[T]) Clear(w http.ResponseWriter, r *http.Request) {
+	for _, name := range s.present(r) {
+		http.SetCookie(w, s.expiredCookie(name))
+	}
+}
+
+// read collects the sealed session value from a single cookie or from its chunks.
+func (s *SessionStore[T]) read(r *http.Request) (string, error) {
+	if c, err := r.Cookie(s.name); err == nil {
+		return c.Value, nil
+	}
+
+	first, err := r.Cookie(s.chunkName(0))
+	if err != nil {
+		return "", ErrNoSession
+	}
+
+	head, err := parseChunk(first.Value)
+	if err != nil {
+		return "", errors.Join(ErrInvalidSession, err)
+	}
+
+	chunks := []string{first.Value}
+	for i := 1; i < head.total; i++ {
+		c, err := r.Cookie(s.chunkName(i))
+		if err != nil {
+			return "", errors.Join(ErrInvalidSession, fmt.Errorf("%w: cookie %s", ErrMissingChunk, s.chunkName(i)))
+		}
+
+		chunks = append(chunks, c.Value)
+	}
+
+	sealed, err := joinChunks(chunks)
+	if err != nil {
+		return "", errors.Join(ErrInvalidSession, err)
+	}
+
+	return sealed, nil
+}
+
+// present returns the names of all session cookies carried by r, chunked or not.
+func (s *SessionStore[T]) present(r *http.Request) []string {
+	var names []string
+
+	for _, c := range r.Cookies() {
+		if c.Name == s.name {
+			names = append(names, c.Name)
+			continue
+		}
+
+		if suffix, ok := strings.CutPrefix(c.Name, s.name+"_"); ok {
+			if _, err := strconv.Atoi(suffix); err == nil {
+				names = append(names, c.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+// chunkName returns the cookie name of the i-th chunk.
+func (s *SessionStore[T]) chunkName(i int) string {
+	return s.name + "_" + strconv.Itoa(i)
+}
+
+// newCookie builds a session cookie from the configured template.
+func (s *SessionStore[T]) newCookie(name, value string) *http.Cookie {
+	c := s.cookie
+	c.Name, c.Value = name, value
+
+	if s.maxAge > 0 {
+		c.MaxAge = int(s.maxAge / time.Second)
+	}
+
+	return &c
+}
+
+// expiredCookie builds a cookie that instructs the client to delete name.
+func (s *SessionStore[T]) expiredCookie(name string) *http.Cookie {
+	c := s.cookie
+	c.Name, c.MaxAge = name, -1
+
+	return &c
+}
+
+// seal prefixes the encoded value with its issue time and protects it with the primary key.
+// The cookie name is bound into the authentication so cookies cannot be swapped between stores.
+func (s *SessionStore[T]) seal(encoded string) (string, error) {
+	payload := strconv.FormatInt(s.now().Unix(), 10) + "|" + encoded
+
+	switch {
+	case len(s.aeads) > 0:
+		aead := s.aeads[0]
+
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+
+		return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(payload), []byte(s.name))), nil
+	case len(s.signKeys) > 0:
+		return payload + "." + base64.RawURLEncoding.EncodeToString(s.sign(s.signKeys[0], payload)), nil
+	default:
+		return payload, nil
+	}
+}
+
+// open reverses seal, trying every configured key, and enforces the maximum age.
+func (s *SessionStore[T]) open(sealed string) (string, error) {
+	var payload string
+
+	switch {
+	case len(s.aeads) > 0:
+		raw, err := base64.RawURLEncoding.DecodeString(sealed)
+		if err != nil {
+			return "", errors.Join(ErrInvalidSession, err)
+		}
+
+		opened := false
+		for _, aead := range s.aeads {
+			if len(raw) < aead.NonceSize() {
+				break
+			}
+
+			plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(s.name))
+			if err == nil {
+				payload, opened = string(plain), true
+				break
+			}
+		}
+
+		if !opened {
+			return "", fmt.Errorf("%w: cannot decrypt with any key", ErrInvalidSession)
+		}
+	case len(s.signKeys) > 0:
+		i := strings.LastIndexByte(sealed, '.')
+		if i < 0 {
+			return "", fmt.Errorf("%w: missing signature", ErrInvalidSession)
+		}
+
+		mac, err := base64.RawURLEncoding.DecodeString(sealed[i+1:])
+		if err != nil {
+			return "", errors.Join(ErrInvalidSession, err)
+		}
+
+		payload = sealed[:i]
+		if !s.verify(payload, mac) {
+			return "", fmt.Errorf("%w: signature mismatch", ErrInvalidSession)
+		}
+	default:
+		payload = sealed
+	}
+
+	issued, encoded, ok := strings.Cut(payload, "|")
+	if !ok {
+		return "", fmt.Errorf("%w: missing issue time", ErrInvalidSession)
+	}
+
+	ts, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return "", errors.Join(ErrInvalidSession, err)
+	}
+
+	if s.maxAge > 0 && s.now().Sub(time.Unix(ts, 0)) > s.maxAge {
+		return "", ErrSessionExpired
+	}
+
+	return encoded, nil
+}
+
+// sign computes the HMAC-SHA256 of the cookie name and payload with key.
+func (s *SessionStore[T]) sign(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s.name))
+	mac.Write([]byte{'|'})
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
+
+// verify reports whether mac authenticates payload under any of the signing keys.
+func (s *SessionStore[T]) verify(payload string, mac []byte) bool {
+	for _, key := range s.signKeys {
+		if hmac.Equal(mac, s.sign(key, payload)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,272 @@
+package compressjson
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sessionData struct {
+	UserID int      `json:"user_id"`
+	Cart   []string `json:"cart,omitempty"`
+}
+
+// requestWithCookies returns a request carrying every non-expired cookie set on rec,
+// simulating a browser sending back what the server stored.
+func requestWithCookies(rec *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	for _, c := range rec.Result().Cookies() {
+		if c.MaxAge >= 0 {
+			req.AddCookie(c)
+		}
+	}
+
+	return req
+}
+
+// randomCart returns n pseudo-random, poorly compressible cart items.
+func randomCart(n int) []string {
+	rng := rand.New(rand.NewSource(int64(n)))
+	cart := make([]string, n)
+
+	for i := range cart {
+		item := make([]byte, 12)
+		for j := range item {
+			item[j] = byte('a' + rng.Intn(26))
+		}
+
+		cart[i] = string(item)
+	}
+
+	return cart
+}
+
+// TestSessionStore is the table-driven test for SessionStore[T]. For every protection mode
+// it saves a session through httptest, checks the cookie attributes and the chunking behavior,
+// loads the session back from the cookies, and verifies that tampering is detected.
+func TestSessionStore(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	cases := []struct {
+		name          string
+		opts          []SessionOption
+		value         sessionData
+		wantChunks    bool
+		detectsTamper bool
+	}{
+		{name: "Plain", value: sessionData{UserID: 1}},
+		{name: "Signed", opts: []SessionOption{WithSigningKeys(key)}, value: sessionData{UserID: 2, Cart: []string{"a"}}, detectsTamper: true},
+		{name: "Encrypted", opts: []SessionOption{WithEncryptionKeys(key)}, value: sessionData{UserID: 3, Cart: []string{"b"}}, detectsTamper: true},
+		{name: "Encrypted and chunked", opts: []SessionOption{WithEncryptionKeys(key), WithMaxCookieSize(512)}, value: sessionData{UserID: 4, Cart: randomCart(200)}, wantChunks: true, detectsTamper: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewSessionStore[sessionData]("sid", nil, tt.opts...)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			assert.NoError(t, store.Save(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody), tt.value))
+
+			cookies := rec.Result().Cookies()
+			if tt.wantChunks {
+				assert.Greater(t, len(cookies), 1, "Large sessions must be split across cookies")
+			} else {
+				assert.Len(t, cookies, 1)
+				assert.Equal(t, "sid", cookies[0].Name)
+			}
+
+			for _, c := range cookies {
+				assert.True(t, c.HttpOnly)
+				assert.True(t, c.Secure)
+				assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+				assert.Equal(t, "/", c.Path)
+				assert.LessOrEqual(t, len(c.Name)+len(c.Value)+1, DefaultMaxCookieSize)
+			}
+
+			got, err := store.Load(requestWithCookies(rec))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, got)
+
+			tampered := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			for i, c := range cookies {
+				if i == 0 {
+					// Flip a character in the middle: the last Base64 characters may carry
+					// padding bits that are ignored when decoding.
+					mid := len(c.Value) / 2
+					flipped := byte('A')
+					if c.Value[mid] == 'A' {
+						flipped = 'B'
+					}
+
+					c.Value = c.Value[:mid] + string(flipped) + c.Value[mid+1:]
+				}
+				tampered.AddCookie(c)
+			}
+
+			_, err = store.Load(tampered)
+			if tt.detectsTamper {
+				assert.ErrorIs(t, err, ErrInvalidSession, "Tampered cookies must be rejected")
+			}
+		})
+	}
+}
+
+// TestSessionStoreRotation verifies that cookies sealed with a retired key remain readable
+// while the key is still configured, and that saving re-seals them with the new primary key.
+func TestSessionStoreRotation(t *testing.T) {
+	t.Parallel()
+
+	oldKey := bytes.Repeat([]byte{0x01}, 16)
+	newKey := bytes.Repeat([]byte{0x02}, 16)
+
+	before, err := NewSessionStore[sessionData]("sid", nil, WithEncryptionKeys(oldKey))
+	assert.NoError(t, err)
+
+	rotating, err := NewSessionStore[sessionData]("sid", nil, WithEncryptionKeys(newKey, oldKey))
+	assert.NoError(t, err)
+
+	after, err := NewSessionStore[sessionData]("sid", nil, WithEncryptionKeys(newKey))
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, before.Save(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody), sessionData{UserID: 7}))
+
+	req := requestWithCookies(rec)
+
+	_, err = after.Load(req)
+	assert.ErrorIs(t, err, ErrInvalidSession, "Cookies sealed with a removed key must be rejected")
+
+	got, err := rotating.Load(req)
+	assert.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	assert.NoError(t, rotating.Save(rec, req, got))
+
+	got, err = after.Load(requestWithCookies(rec))
+	assert.NoError(t, err, "Saved session must be sealed with the new primary key")
+	assert.Equal(t, sessionData{UserID: 7}, got)
+}
+
+// TestSessionStoreLifecycle covers missing sessions, expiration, re-chunking cleanup and Clear.
+func TestSessionStoreLifecycle(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 8, 9, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewSessionStore[sessionData]("sid", nil,
+		WithSigningKeys([]byte("secret")),
+		WithSessionMaxAge(time.Hour),
+		WithMaxCookieSize(512),
+	)
+	assert.NoError(t, err)
+	store.now = func() time.Time { return now }
+
+	_, err = store.Load(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.ErrorIs(t, err, ErrNoSession)
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, store.Save(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody), sessionData{UserID: 1, Cart: randomCart(100)}))
+
+	chunked := requestWithCookies(rec)
+	assert.Greater(t, len(chunked.Cookies()), 1)
+	assert.Equal(t, 3600, rec.Result().Cookies()[0].MaxAge)
+
+	// Shrinking the session to a single cookie must expire every chunk cookie.
+	rec = httptest.NewRecorder()
+	assert.NoError(t, store.Save(rec, chunked, sessionData{UserID: 1}))
+
+	var expired, set int
+	for _, c := range rec.Result().Cookies() {
+		if c.MaxAge < 0 {
+			expired++
+			assert.True(t, strings.HasPrefix(c.Name, "sid_"))
+		} else {
+			set++
+			assert.Equal(t, "sid", c.Name)
+		}
+	}
+
+	assert.Equal(t, 1, set)
+	assert.Equal(t, len(chunked.Cookies()), expired)
+
+	single := requestWithCookies(rec)
+
+	now = now.Add(2 * time.Hour)
+	_, err = store.Load(single)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	rec = httptest.NewRecorder()
+	store.Clear(rec, single)
+	assert.Len(t, rec.Result().Cookies(), 1)
+	assert.Equal(t, -1, rec.Result().Cookies()[0].MaxAge)
+}
+
+// TestSessionStoreCookieTemplate verifies that only the documented attributes of a cookie template
+// are used, so that expiration fields of the template do not leak into session or expired cookies.
+func TestSessionStoreCookieTemplate(t *testing.T) {
+	t.Parallel()
+
+	template := http.Cookie{
+		Name:        "ignored",
+		Value:       "ignored",
+		Path:        "/app",
+		Domain:      "example.com",
+		Secure:      true,
+		HttpOnly:    true,
+		SameSite:    http.SameSiteStrictMode,
+		Partitioned: true,
+		MaxAge:      3600,
+		Expires:     time.Date(2033, 5, 18, 0, 0, 0, 0, time.UTC),
+		RawExpires:  "Wed, 18 May 2033 00:00:00 GMT",
+		Raw:         "ignored=ignored",
+		Unparsed:    []string{"Priority=High"},
+	}
+
+	store, err := NewSessionStore[sessionData]("sid", nil, WithSigningKeys([]byte("secret")), WithCookieTemplate(template))
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, store.Save(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody), sessionData{UserID: 1}))
+
+	header := rec.Header().Get("Set-Cookie")
+	assert.True(t, strings.HasPrefix(header, "sid="), "Unexpected cookie: %s", header)
+	assert.Contains(t, header, "Path=/app")
+	assert.Contains(t, header, "Domain=example.com")
+	assert.Contains(t, header, "SameSite=Strict")
+	assert.Contains(t, header, "Partitioned")
+	assert.NotContains(t, header, "Expires=")
+	assert.NotContains(t, header, "Max-Age=")
+	assert.NotContains(t, header, "Priority")
+
+	session := requestWithCookies(rec)
+
+	rec = httptest.NewRecorder()
+	store.Clear(rec, session)
+
+	header = rec.Header().Get("Set-Cookie")
+	assert.Contains(t, header, "Max-Age=0")
+	assert.NotContains(t, header, "2033", "Expired cookies must not inherit the template expiration")
+}
+
+// TestNewSessionStoreErrors verifies that invalid configurations are rejected up front.
+func TestNewSessionStoreErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSessionStore[sessionData]("", nil)
+	assert.Error(t, err)
+
+	_, err = NewSessionStore[sessionData]("sid", nil, WithEncryptionKeys([]byte("short")))
+	assert.Error(t, err)
+
+	_, err = NewSessionStore[sessionData]("sid", nil, WithSigningKeys(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package compressjson
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver is a minimal database/sql driver that keeps a single column value in memory.
+// Every statement with arguments stores its first argument; every statement without
+// arguments returns the stored value as a one-row, one-column result set.
+type fakeDriver struct {
+	mu     sync.Mutex
+	stored driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{driver: d}, nil }
+
+// Connect and Driver implement driver.Connector, so tests can use sql.OpenDB
+// without registering the driver globally.
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return d.Open("") }
+func (d *fakeDriver) Driver() driver.Driver                        { return d }
+
+type fakeConn struct{ driver *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return &fakeStmt{driver: c.driver}, nil }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type fakeStmt struct{ driver *fakeDriver }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+
+	s.driver.stored = args[0]
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+
+	return &fakeRows{value: s.driver.stored}, nil
+}
+
+type fakeRows struct {
+	value driver.Value
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"payload"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	r.done = true
+	dest[0] = r.value
+
+	return nil
+}
+
+// TestSQLValue is the table-driven test for SQLValue[T] going through a real *sql.DB backed
+// by the in-memory fake driver. It verifies the representation handed to the driver for every
+// format, the round trip back through Scan, and that NULL is mapped to an invalid value.
+func TestSQLValue(t *testing.T) {
+	t.Parallel()
+
+	drv := &fakeDriver{}
+
+	db := sql.OpenDB(drv)
+	t.Cleanup(func() { _ = db.Close() })
+
+	cases := []struct {
+		name       string
+		input      SQLValue[user]
+		storedType any
+	}{
+		{name: "Text column", input: NewSQLValue(user{ID: 1, Name: "Alice"}, SQLFormatText), storedType: ""},
+		{name: "Binary column", input: NewSQLValue(user{ID: 2, Email: "bob@example.com"}, SQLFormatBinary), storedType: []byte(nil)},
+		{name: "NULL", input: SQLValue[user]{}, storedType: nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Exec("INSERT", tt.input)
+			assert.NoError(t, err, "Exec must accept SQLValue as an argument")
+			assert.IsType(t, tt.storedType, drv.stored, "Driver received an unexpected representation")
+
+			got := SQLValue[user]{V: user{ID: 99}, Valid: true}
+			err = db.QueryRow("SELECT").Scan(&got)
+
+			assert.NoError(t, err, "Scan must succeed for values written by Value")
+			assert.Equal(t, tt.input, got, "Scanned value does not match the stored one")
+		})
+	}
+}
+
+// TestSQLValueScan covers the source types accepted by Scan directly, including text
+// columns that drivers return as byte slices and unsupported or corrupted sources.
+func TestSQLValueScan(t *testing.T) {
+	t.Parallel()
+
+	tr := newTranscoder[user]()
+	want := user{ID: 5, Name: "Eve"}
+
+	encoded, err := tr.Encode(want)
+	assert.NoError(t, err)
+
+	compressed, err := tr.encodeBytes(want)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		src     any
+		format  SQLFormat
+		wantErr bool
+	}{
+		{name: "String", src: encoded, format: SQLFormatText},
+		{name: "Text as bytes", src: []byte(encoded), format: SQLFormatText},
+		{name: "Raw frame", src: compressed, format: SQLFormatBinary},
+		{name: "Corrupted text", src: "corrupted", wantErr: true},
+		{name: "Corrupted frame", src: append(append([]byte(nil), zstdMagic...), 0x00), wantErr: true},
+		{name: "Unsupported type", src: int64(42), wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got SQLValue[user]
+			err := got.Scan(tt.src)
+
+			if tt.wantErr {
+				assert.Error(t, err, "Scan must fail for %T source", tt.src)
+				assert.False(t, got.Valid, "Value must stay invalid on failure")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, NewSQLValue(want, tt.format), got)
+		})
+	}
+}
@@ -6,6 +6,13 @@ import (
 	"github.com/spacemagneto/compressjson/lib"
 )
 
+// Shared byte-level stages for integrations that work on untyped data, such as HTTP bodies,
+// or that need a single stage outside a typed transcoder. All of them are stateless.
+var (
+	zstdStage   = lib.NewZSTDTranscoder()
+	base64Stage = lib.NewBase64Transcoder()
+)
+
 // Transcoder is a concrete, high-performance implementation of transcoder[T]
 // designed for low-latency, high-throughput scenarios. It is safe for concurrent use
 // by multiple goroutines and reuses internal buffers and native resources across calls.
@@ -14,17 +21,36 @@ type transcoder[T any] struct {
 	jsonTranscoder     *lib.JSONTranscoder[T]
 	standardTranscoder *lib.ZSTDTranscoder
 	binaryTranscoder   *lib.Base64Transcoder
+	opts               options
+	memo               *decodeMemo[T]
 }
 
 // NewTranscoder creates a ready-to-use transcoder for type T.
 // All internal components are initialized once and reused forever.
 // The returned value satisfies Transcoder[T] and can be shared globally.
-func NewTranscoder[T any]() Transcoder[T] {
-	return &transcoder[T]{
-		jsonTranscoder:     lib.NewJSONTranscoder[T](),
-		standardTranscoder: lib.NewZSTDTranscoder(),
+// Optional behavior such as logging can be enabled with the given options.
+func NewTranscoder[T any](opts ...Option) Transcoder[T] {
+	return newTranscoder[T](opts...)
+}
+
+// newTranscoder is the concrete constructor behind NewTranscoder. It is used by
+// package-level integrations that need the binary stages in addition to the Transcoder API.
+func newTranscoder[T any](opts ...Option) *transcoder[T] {
+	o := newOptions(opts)
+	zstdOpts := lib.ZSTDOptions{Level: o.compressionLevel, DisableChecksum: o.checksum&ChecksumFrame == 0}
+
+	t := &transcoder[T]{
+		jsonTranscoder:     lib.NewJSONTranscoderWithOptions[T](o.json),
+		standardTranscoder: lib.NewZSTDTranscoderWithOptions(zstdOpts),
 		binaryTranscoder:   lib.NewBase64Transcoder(),
+		opts:               o,
+	}
+
+	if t.opts.decodeCacheSize > 0 {
+		t.memo = newDecodeMemo[T](t.opts.decodeCacheSize)
 	}
+
+	return t
 }
 
 // Encode converts a value of type T into a compact, text-safe string.
@@ -32,14 +58,9 @@ func NewTranscoder[T any]() Transcoder[T] {
 // and finally encoded to standard Base64. Any error aborts the process
 // and returns a wrapped error with context.
 func (t *transcoder[T]) Encode(src T) (string, error) {
-	jsonBytes, err := t.jsonTranscoder.Marshal(src)
-	if err != nil {
-		return "", errors.Join(errors.New("failed to marshal JSON"), err)
-	}
-
-	compressedBytes, err := t.standardTranscoder.Compress(jsonBytes)
+	compressedBytes, err := t.encodeBytes(src)
 	if err != nil {
-		return "", errors.Join(errors.New("failed to compress with Zstd"), err)
+		return "", err
 	}
 
 	return t.binaryTranscoder.Encode(compressedBytes)
@@ -49,18 +70,127 @@ func (t *transcoder[T]) Encode(src T) (string, error) {
 // The process reverses the encoding steps: Base64 decoding, Z - standard decompression,
 // and JSON unmarshalling. On success the original value is returned; on failure
 // the zero value of T is returned along with a descriptive wrapped error.
+// When a decode cache is configured, previously decoded inputs are served from it.
 func (t *transcoder[T]) Decode(src string) (T, error) {
+	if t.memo != nil {
+		if entry, ok := t.memo.get(src); ok {
+			return entry, nil
+		}
+	}
+
 	var entry T
 
 	compressedBytes, err := t.binaryTranscoder.Decode(src)
 	if err != nil {
-		return entry, errors.Join(errors.New("failed to decode Base64"), err)
+		t.logDecodeFailure("base64", src, err)
+		return entry, errors.Join(ErrDecodeBase64, err)
+	}
+
+	entry, err = t.decodeBytes(compressedBytes)
+	if err != nil {
+		return entry, err
+	}
+
+	if t.memo != nil {
+		t.memo.add(src, entry)
+	}
+
+	return entry, nil
+}
+
+// encodeBytes runs the binary part of the pipeline: JSON marshaling followed by
+// Z - standard compression. It is shared by Encode and by integrations that store
+// the compressed frame directly, such as binary database columns. When a schema version
+// is configured, the frame is wrapped into an envelope recording that version.
+func (t *transcoder[T]) encodeBytes(src T) ([]byte, error) {
+	frame, err := t.encodeFrame(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return versionedFrame(frame, t.opts.schemaVersion, t.opts.checksum&ChecksumCRC32C != 0)
+}
+
+// encodeFrame marshals src to JSON and compresses it into a single bare Z - standard frame.
+func (t *transcoder[T]) encodeFrame(src T) ([]byte, error) {
+	jsonBytes, err := t.jsonTranscoder.Marshal(src)
+	if err != nil {
+		t.logEncodeFailure("json", src, err)
+		return nil, errors.Join(ErrMarshalJSON, err)
 	}
 
-	jsonBytes, err := t.standardTranscoder.Decompress(compressedBytes)
+	compressedBytes, err := t.standardTranscoder.Compress(jsonBytes)
+	if err != nil {
+		t.logEncodeFailure("zstd", src, err)
+		return nil, errors.Join(ErrCompress, err)
+	}
+
+	return compressedBytes, nil
+}
+
+// decodeBytes reverses encodeBytes: it accepts a bare Z - standard frame or a versioned envelope,
+// decompresses the frame and unmarshals the resulting JSON into a new value of type T.
+func (t *transcoder[T]) decodeBytes(src []byte) (T, error) {
+	var entry T
+
+	frame, version, err := splitVersionedFrame(src)
+	if err != nil {
+		t.logDecodeFailure("envelope", string(src), err)
+		return entry, err
+	}
+
+	return t.decodeFrame(frame, version)
+}
+
+// decodeFrame decompresses a bare Z - standard frame holding JSON written with the given schema
+// version, migrates the document to the current version and unmarshals it.
+func (t *transcoder[T]) decodeFrame(src []byte, version uint32) (T, error) {
+	var entry T
+
+	jsonBytes, err := t.frameJSON(src, version)
+	if err != nil {
+		return entry, err
+	}
+
+	return t.decodeJSON(jsonBytes)
+}
+
+// frameJSON decompresses a bare Z - standard frame holding JSON written with the given schema
+// version and returns the document migrated to the current version.
+func (t *transcoder[T]) frameJSON(src []byte, version uint32) ([]byte, error) {
+	jsonBytes, err := t.standardTranscoder.Decompress(src)
+	if err != nil {
+		t.logDecodeFailure("zstd", string(src), err)
+		return nil, decompressError(err)
+	}
+
+	migrated, err := t.migrate(jsonBytes, version)
+	if err != nil {
+		t.logDecodeFailure("migrate", string(jsonBytes), err)
+		return nil, err
+	}
+
+	return migrated, nil
+}
+
+// schemaVersion returns the schema version written by encodeBytes.
+func (t *transcoder[T]) schemaVersion() uint32 {
+	return t.opts.schemaVersion
+}
+
+// checksumMode returns the checksums written by encodeBytes.
+func (t *transcoder[T]) checksumMode() ChecksumMode {
+	return t.opts.checksum
+}
+
+// decodeJSON is the final stage of every decode path: it unmarshals plain JSON
+// into a new value of type T.
+func (t *transcoder[T]) decodeJSON(src []byte) (T, error) {
+	entry, err := t.jsonTranscoder.Unmarshal(src)
 	if err != nil {
-		return entry, errors.Join(errors.New("failed to decompress Zstd"), err)
+		t.logDecodeFailure("json", string(src), err)
+		return entry, errors.Join(ErrUnmarshalJSON, err)
 	}
 
-	return t.jsonTranscoder.Unmarshal(jsonBytes)
+	return entry, nil
 }
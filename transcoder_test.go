@@ -8,6 +8,8 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/spacemagneto/compressjson/lib"
 )
 
 type user struct {
@@ -137,3 +139,100 @@ func TestPipelineTranscoder(t *testing.T) {
 		spew.Dump(string(data))
 	})
 }
+
+// TestStrictDecoding is the table-driven test for the strict decoding options. Values are encoded
+// with a lax transcoder for a wider type and decoded with the configured options, which must either
+// accept the input or reject it with ErrUnmarshalJSON wrapping the specific cause.
+func TestStrictDecoding(t *testing.T) {
+	t.Parallel()
+
+	type account struct {
+		user
+		Role string `json:"role"`
+	}
+
+	withRole, err := NewTranscoder[account]().Encode(account{user: user{ID: 1}, Role: "admin"})
+	assert.NoError(t, err)
+
+	nested, err := NewTranscoder[[][]int]().Encode([][]int{{1}})
+	assert.NoError(t, err)
+
+	long, err := NewTranscoder[user]().Encode(user{ID: 2, Name: strings.Repeat("x", 65)})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		opts    []Option
+		input   string
+		wantErr error
+	}{
+		{name: "Lax decoding drops unknown fields", input: withRole},
+		{name: "Unknown fields rejected", opts: []Option{WithDisallowUnknownFields()}, input: withRole, wantErr: ErrUnmarshalJSON},
+		{name: "Depth limit", opts: []Option{WithMaxDepth(1)}, input: nested, wantErr: lib.ErrMaxDepth},
+		{name: "String length limit", opts: []Option{WithMaxStringLength(64)}, input: long, wantErr: lib.ErrMaxStringLength},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTranscoder[user](tt.opts...).Decode(tt.input)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, ErrUnmarshalJSON)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	got, err := NewTranscoder[map[string]any](WithUseNumber()).Decode(withRole)
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("1"), got["id"])
+}
+
+// TestCanonicalEncoding verifies that with canonical JSON enabled, equal values encode to
+// byte-identical strings regardless of map construction order, number representation and
+// concurrent use of the transcoder.
+func TestCanonicalEncoding(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTranscoder[map[string]any](WithCanonicalJSON())
+
+	build := func(reverse bool) map[string]any {
+		m := make(map[string]any)
+		for i := 0; i < 64; i++ {
+			k := i
+			if reverse {
+				k = 63 - i
+			}
+
+			m[strings.Repeat(string(rune('a'+k%26)), k/26+1)] = map[string]any{"n": k, "html": "<" + strings.Repeat("&", k%3) + ">"}
+		}
+
+		return m
+	}
+
+	want, err := tr.Encode(build(false))
+	assert.NoError(t, err)
+
+	results := make(chan string, 32)
+	for i := 0; i < cap(results); i++ {
+		go func(reverse bool) {
+			encoded, _ := tr.Encode(build(reverse))
+			results <- encoded
+		}(i%2 == 1)
+	}
+
+	for i := 0; i < cap(results); i++ {
+		assert.Equal(t, want, <-results)
+	}
+
+	floats, err := tr.Encode(map[string]any{"a": 1.0, "b": json.Number("2.50")})
+	assert.NoError(t, err)
+
+	ints, err := tr.Encode(map[string]any{"b": 2.5, "a": 1})
+	assert.NoError(t, err)
+
+	assert.Equal(t, ints, floats)
+}